@@ -0,0 +1,118 @@
+package rkt
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// migrationLockName names the advisory lock held while migrating on start
+	migrationLockName = "rkt:migrate_on_start"
+
+	// migrationLockTimeout bounds the wait for another instance to finish
+	// migrating
+	migrationLockTimeout = 5 * time.Minute
+)
+
+/*
+migrateOnStart applies pending migrations when MIGRATE_ON_START is set. It
+is called by New once the database is connected. Several instances booting
+together take turns through an advisory lock on postgres and mysql; mongodb
+relies on the locking of the golang-migrate driver, and sqlite databases
+are not shared between hosts. A dirty schema is never migrated, since the
+failed migration has to be repaired by hand first.
+
+Embedded migrations and Go migrations are only seen here when
+UseMigrationsFS and UseMigrations were called before New.
+*/
+func (r *RKT) migrateOnStart(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, migrationLockTimeout)
+	defer cancel()
+
+	unlock, err := r.lockMigrations(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	dsn := r.MigrationDSN()
+
+	before, err := r.MigrationStatus(dsn)
+	if err != nil {
+		return err
+	}
+	if before.Dirty {
+		return fmt.Errorf("refusing to start: the database is dirty at version %d; fix it by hand and run rkt migrate force <version>", before.Version)
+	}
+
+	pending := before.Pending()
+	if len(pending) == 0 {
+		r.Logger.Info("Database schema is up to date", "version", before.Version)
+		return nil
+	}
+
+	upErr := r.MigrateUp(dsn)
+
+	// log what was applied even when a later migration failed
+	after, err := r.MigrationStatus(dsn)
+	if err != nil {
+		return errors.Join(upErr, err)
+	}
+	for _, mi := range pending {
+		if mi.Version <= after.Version && !(mi.Version == after.Version && after.Dirty) {
+			r.Logger.Info("Applied migration", "version", mi.Version, "name", mi.Name)
+		}
+	}
+
+	if upErr != nil {
+		return fmt.Errorf("migrating to the latest version: %w", upErr)
+	}
+	return nil
+}
+
+// lockMigrations takes the migration advisory lock on a connection of its
+// own, and returns the function that releases it
+func (r *RKT) lockMigrations(ctx context.Context) (func(), error) {
+	family := sqlFamily(r.config.Database.Type)
+	if r.DB.Pool == nil || (family != "postgres" && family != "mysql") {
+		return func() {}, nil
+	}
+
+	conn, err := r.DB.Pool.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Logger.Debug("Waiting for the migration lock")
+
+	var release string
+	switch family {
+	case "postgres":
+		_, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock(hashtext($1))", migrationLockName)
+		release = "SELECT pg_advisory_unlock(hashtext($1))"
+
+	case "mysql":
+		var locked sql.NullInt64
+		err = conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", migrationLockName, int(migrationLockTimeout.Seconds())).Scan(&locked)
+		if err == nil && locked.Int64 != 1 {
+			err = errors.New("timed out")
+		}
+		release = "SELECT RELEASE_LOCK(?)"
+	}
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("taking the migration lock: %w", err)
+	}
+
+	return func() {
+		// the lock is held by the session, so closing the connection would
+		// release it too; the explicit release keeps the pooled conn reusable
+		if _, err := conn.ExecContext(context.Background(), release, migrationLockName); err != nil {
+			r.Logger.Warn("Could not release the migration lock", "error", err)
+		}
+		_ = conn.Close()
+	}, nil
+}
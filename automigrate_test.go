@@ -0,0 +1,67 @@
+package rkt
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func withMigrateOnStart(cfg *Config) {
+	withSQLite(cfg)
+	cfg.Database.MigrateOnStart = true
+}
+
+func TestRKT_MigrateOnStart(t *testing.T) {
+	app, dsn := newMigrationsTestApp(t)
+
+	if err := app.MigrateTo(1, dsn); err != nil {
+		t.Fatal(err)
+	}
+
+	booted := newTestAppIn(t, app.RootPath, withMigrateOnStart)
+
+	status, err := booted.MigrationStatus(booted.MigrationDSN())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Version != 3 || len(status.Pending()) != 0 {
+		t.Errorf("expected every migration to be applied at start, got %+v", status)
+	}
+
+	// a second boot finds nothing to do
+	newTestAppIn(t, app.RootPath, withMigrateOnStart)
+}
+
+func TestRKT_MigrateOnStartRefusesDirtySchema(t *testing.T) {
+	app, dsn := newMigrationsTestApp(t)
+
+	broken := filepath.Join(app.RootPath, "migrations", "4_broken.up.sql")
+	if err := os.WriteFile(broken, []byte("create table nope (;"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.MigrateUp(dsn); err == nil {
+		t.Fatal("expected the broken migration to fail")
+	}
+
+	// even once the file is fixed, the dirty flag has to be cleared by hand
+	if err := os.WriteFile(broken, []byte("create table fixed (id integer);"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	booted := &RKT{}
+	err := booted.NewWithConfig(app.RootPath, testConfig(withMigrateOnStart))
+	var cfgErr *ConfigError
+	if !errors.As(err, &cfgErr) || !strings.Contains(err.Error(), "dirty at version 4") {
+		t.Fatalf("expected New to refuse a dirty schema, got %v", err)
+	}
+
+	if err := app.MigrateForceVersion(3, dsn); err != nil {
+		t.Fatal(err)
+	}
+	if err := booted.NewWithConfig(app.RootPath, testConfig(withMigrateOnStart)); err != nil {
+		t.Fatal(err)
+	}
+	defer booted.Close()
+}
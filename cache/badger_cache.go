@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"errors"
 	"time"
 
 	"github.com/dgraph-io/badger/v3"
@@ -9,10 +10,11 @@ import (
 type BadgerCache struct {
 	Conn *badger.DB
 	Prefix string
+	Stats
 }
 
 func (b *BadgerCache) Has(str string) (bool, error) {
-	_, err := b.Get(str)
+	_, err := b.get(str)
 	if err != nil {
 		return false, nil
 	}
@@ -20,6 +22,20 @@ func (b *BadgerCache) Has(str string) (bool, error) {
 }
 
 func (b *BadgerCache) Get(str string) (interface{}, error) {
+	item, err := b.get(str)
+	if err != nil {
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			b.miss()
+		}
+		return nil, err
+	}
+	b.hit()
+
+	return item, nil
+}
+
+// get reads a key without counting it as a hit or miss
+func (b *BadgerCache) get(str string) (interface{}, error) {
 	var fromCache []byte
 
 	err := b.Conn.View(func(txn *badger.Txn) error {
@@ -3,7 +3,9 @@ package cache
 import (
 	"bytes"
 	"encoding/gob"
+	"errors"
 	"fmt"
+	"sync/atomic"
 
 	"github.com/gomodule/redigo/redis"
 )
@@ -17,9 +19,40 @@ type Cache interface {
 	Empty() error
 }
 
+// StatsReporter is implemented by caches that count hits and misses on Get
+type StatsReporter interface {
+	Hits() uint64
+	Misses() uint64
+}
+
+// Stats counts hits and misses. It is embedded by the caches in this package.
+type Stats struct {
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// Hits returns the number of Get calls that found the key
+func (s *Stats) Hits() uint64 {
+	return s.hits.Load()
+}
+
+// Misses returns the number of Get calls that did not find the key
+func (s *Stats) Misses() uint64 {
+	return s.misses.Load()
+}
+
+func (s *Stats) hit() {
+	s.hits.Add(1)
+}
+
+func (s *Stats) miss() {
+	s.misses.Add(1)
+}
+
 type RedisCache struct {
 	Conn   *redis.Pool
 	Prefix string
+	Stats
 }
 
 type Entry map[string]interface{}
@@ -66,8 +99,12 @@ func (c *RedisCache) Get(str string) (interface{}, error) {
 
 	cacheEntry, err := redis.Bytes(conn.Do("GET", key))
 	if err != nil {
+		if errors.Is(err, redis.ErrNil) {
+			c.miss()
+		}
 		return nil, err
 	}
+	c.hit()
 
 	decoded, err := decode(string(cacheEntry))
 	if err != nil {
@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned by MemoryCache.Get for missing or expired keys
+var ErrNotFound = errors.New("cache: key not found")
+
+// MemoryCache keeps entries in process memory. It is meant for tests and
+// single instance development setups; values are gob encoded just like in
+// the redis and badger caches, so the same types work everywhere.
+type MemoryCache struct {
+	mu      sync.RWMutex
+	entries map[string]memoryEntry
+	Stats
+}
+
+type memoryEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// NewMemoryCache returns an empty in-memory cache
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{
+		entries: make(map[string]memoryEntry),
+	}
+}
+
+func (m *MemoryCache) Has(str string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, ok := m.lookup(str)
+	return ok, nil
+}
+
+func (m *MemoryCache) Get(str string) (interface{}, error) {
+	m.mu.RLock()
+	e, ok := m.lookup(str)
+	m.mu.RUnlock()
+
+	if !ok {
+		m.miss()
+		return nil, ErrNotFound
+	}
+	m.hit()
+
+	decoded, err := decode(string(e.value))
+	if err != nil {
+		return nil, err
+	}
+
+	return decoded[str], nil
+}
+
+func (m *MemoryCache) Set(str string, value interface{}, expires ...int) error {
+	entry := Entry{}
+	entry[str] = value
+	encoded, err := encode(entry)
+	if err != nil {
+		return err
+	}
+
+	e := memoryEntry{value: encoded}
+	if len(expires) > 0 {
+		e.expires = time.Now().Add(time.Duration(expires[0]) * time.Second)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries[str] = e
+	return nil
+}
+
+func (m *MemoryCache) Forget(str string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, str)
+	return nil
+}
+
+func (m *MemoryCache) EmptyByMatch(str string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key := range m.entries {
+		if strings.HasPrefix(key, str) {
+			delete(m.entries, key)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryCache) Empty() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries = make(map[string]memoryEntry)
+	return nil
+}
+
+// lookup returns an entry that has not expired; the caller holds the lock
+func (m *MemoryCache) lookup(str string) (memoryEntry, bool) {
+	e, ok := m.entries[str]
+	if !ok {
+		return e, false
+	}
+	if !e.expires.IsZero() && time.Now().After(e.expires) {
+		return e, false
+	}
+	return e, true
+}
@@ -9,7 +9,7 @@ import (
 
 func doAuth() error {
 	// migrations
-	dbType := r.DB.DataType
+	dbType := templateDBType()
 	fileName := fmt.Sprintf("%d_create_auth_tables", time.Now().UnixMicro())
 	upFile := r.RootPath + "/migrations/" + fileName + ".up.sql"
 	downFile := r.RootPath + "/migrations/" + fileName + ".down.sql"
@@ -19,7 +19,13 @@ func doAuth() error {
 		exitGracefully(err)
 	}
 
-	err = copyDataToFile([]byte("drop table if exists users cascade; drop table if exists tokens cascade; drop table if exists remember_tokens;"), downFile)
+	down := "drop table if exists users cascade; drop table if exists tokens cascade; drop table if exists remember_tokens;"
+	if dbType == "mysql" || dbType == "sqlite" {
+		// mysql and sqlite have no cascading drops, so the tables go in foreign key order
+		down = "drop table if exists remember_tokens; drop table if exists tokens; drop table if exists users;"
+	}
+
+	err = copyDataToFile([]byte(down), downFile)
 	if err != nil {
 		exitGracefully(err)
 	}
@@ -45,6 +51,18 @@ func doAuth() error {
 		exitGracefully(err)
 	}
 
+	err = ensureModelsFile()
+	if err != nil {
+		exitGracefully(err)
+	}
+
+	for _, model := range [][2]string{{"Users", "User"}, {"Tokens", "Token"}, {"RememberTokens", "RememberToken"}} {
+		err = addModelField(model[0], model[1])
+		if err != nil {
+			exitGracefully(err)
+		}
+	}
+
 	// copy over middleware
 	err = copyFilefromTemplate("templates/middleware/auth.go.txt", r.RootPath+"/middleware/auth.go")
 	if err != nil {
@@ -95,7 +113,8 @@ func doAuth() error {
 	color.Yellow("  - user and token models created")
 	color.Yellow("  - auth middleware created")
 	color.Yellow("")
-	color.Yellow("Don't forget to add user and token models in data/models.go, and to add appropriate middleware to your routes!")
+	color.Yellow("Models are added to data/models.go; call data.New(app.DB) once at startup,")
+	color.Yellow("and don't forget to add appropriate middleware to your routes!")
 
 	return nil
 }
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// doCert generates a self-signed certificate for localhost into the tls folder
+// of the project, so that SECURE=true works in development without mkcert or openssl
+func doCert() error {
+	certDir := r.RootPath + "/tls"
+	certFile := certDir + "/cert.pem"
+	keyFile := certDir + "/key.pem"
+
+	if fileExists(certFile) || fileExists(keyFile) {
+		return errors.New("a certificate already exists in " + certDir)
+	}
+
+	err := r.CreateDirIfNotExist(certDir)
+	if err != nil {
+		return err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return err
+	}
+
+	template := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"rkt development"}, CommonName: "localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1"), net.IPv6loopback},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return err
+	}
+
+	keyBytes, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyBytes}), 0600)
+	if err != nil {
+		return err
+	}
+
+	color.Yellow("  - self-signed certificate for localhost written to tls/cert.pem and tls/key.pem")
+	color.Yellow("")
+	color.Yellow("Set SECURE=true, TLS_CERT_FILE=tls/cert.pem and TLS_KEY_FILE=tls/key.pem in .env to serve HTTPS.")
+	color.Yellow("Don't commit tls/key.pem to version control!")
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"path/filepath"
+	"testing"
+)
+
+func TestDoCert(t *testing.T) {
+	r.RootPath = t.TempDir()
+
+	if err := doCert(); err != nil {
+		t.Fatal(err)
+	}
+
+	pair, err := tls.LoadX509KeyPair(filepath.Join(r.RootPath, "tls", "cert.pem"), filepath.Join(r.RootPath, "tls", "key.pem"))
+	if err != nil {
+		t.Fatalf("expected a matching certificate and key, got %v", err)
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cert.VerifyHostname("localhost"); err != nil {
+		t.Error(err)
+	}
+	if err := cert.VerifyHostname("127.0.0.1"); err != nil {
+		t.Error(err)
+	}
+
+	if err := doCert(); err == nil {
+		t.Error("expected an existing certificate not to be overwritten")
+	}
+}
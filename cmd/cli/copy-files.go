@@ -4,6 +4,9 @@ import (
 	"embed"
 	"errors"
 	"os"
+	"strings"
+
+	"github.com/fatih/color"
 )
 
 //go:embed templates
@@ -41,3 +44,40 @@ func fileExists(fileToCheck string) bool {
 	}
 	return true
 }
+
+// ensureModelsFile writes data/models.go, which every generated model
+// registers itself with, unless the app already has one
+func ensureModelsFile() error {
+	modelsFile := r.RootPath + "/data/models.go"
+	if fileExists(modelsFile) {
+		return nil
+	}
+	return copyFilefromTemplate("templates/data/models.go.txt", modelsFile)
+}
+
+// modelsMarker is the line in data/models.go that model fields go above
+const modelsMarker = "\t// rkt:models\n"
+
+// addModelField adds a field for a model to the Models struct in
+// data/models.go, or tells the user to do it when the marker is gone
+func addModelField(field, modelType string) error {
+	modelsFile := r.RootPath + "/data/models.go"
+	content, err := os.ReadFile(modelsFile)
+	if err != nil {
+		return err
+	}
+
+	line := "\t" + field + " " + modelType + "\n"
+	models := string(content)
+	if strings.Contains(models, line) {
+		return nil
+	}
+
+	if !strings.Contains(models, modelsMarker) {
+		color.Yellow("Don't forget to add %s %s to the Models struct in data/models.go", field, modelType)
+		return nil
+	}
+
+	models = strings.Replace(models, modelsMarker, line+modelsMarker, 1)
+	return copyDataToFile([]byte(models), modelsFile)
+}
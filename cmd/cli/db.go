@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/m-goku/rkt"
+)
+
+func doDB(arg2, arg3 string) error {
+	switch arg2 {
+	case "schema":
+		return doSchema(arg3)
+	default:
+		showHelp()
+	}
+	return nil
+}
+
+// doSchema writes the schema of the database to file, schema.sql in the
+// project root by default
+func doSchema(file string) error {
+	if file == "" {
+		file = filepath.Join(r.RootPath, "schema.sql")
+	}
+
+	db, err := r.OpenDB(cfg.Database.Type, cfg.Database.BuildDSN())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// dump to memory first, so that a failure leaves the old file alone
+	var buf bytes.Buffer
+	err = rkt.Database{DataType: cfg.Database.Type, Pool: db}.DumpSchema(context.Background(), &buf)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(file, buf.Bytes(), 0644)
+	if err != nil {
+		return err
+	}
+
+	color.Green("Wrote the schema to %s", file)
+	return nil
+}
@@ -1,12 +1,14 @@
 package main
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/fatih/color"
 	"github.com/joho/godotenv"
+	"github.com/m-goku/rkt"
 )
 
 func setup(arg1, arg2 string) {
@@ -21,39 +23,57 @@ func setup(arg1, arg2 string) {
 			exitGracefully(err)
 		}
 
+		cfg, err = rkt.LoadConfig(path)
+		if err != nil {
+			exitGracefully(err)
+		}
+
+		cfg.Database.ResolvePath(path)
+
 		r.RootPath = path
-		r.DB.DataType = os.Getenv("DATABASE_TYPE")
+		r.DB.DataType = cfg.Database.Type
 	}
 }
 
 func getDSN() string {
-	dbType := r.DB.DataType
+	return cfg.Database.MigrationDSN()
+}
+
+// hasFlag reports whether a flag such as --go was given after the command
+func hasFlag(flag string) bool {
+	for _, arg := range os.Args[2:] {
+		if arg == flag {
+			return true
+		}
+	}
+	return false
+}
 
-	if dbType == "pgx" {
-		dbType = "postgres"
+// appModule reads the module path of the app from its go.mod
+func appModule() (string, error) {
+	data, err := os.ReadFile(filepath.Join(r.RootPath, "go.mod"))
+	if err != nil {
+		return "", err
 	}
 
-	if dbType == "postgres" {
-		return os.Getenv("DATABASE_CONN_STR")
-		// if os.Getenv("DATABASE_PASS") != "" {
-		// 	// 	dsn = fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=%s",
-		// 	// 		os.Getenv("DATABASE_USER"),
-		// 	// 		os.Getenv("DATABASE_PASS"),
-		// 	// 		os.Getenv("DATABASE_HOST"),
-		// 	// 		os.Getenv("DATABASE_PORT"),
-		// 	// 		os.Getenv("DATABASE_NAME"),
-		// 	// 		os.Getenv("DATABASE_SSL_MODE"))
-		// 	// } else {
-		// 	// 	dsn = fmt.Sprintf("postgres://%s@%s:%s/%s?sslmode=%s",
-		// 	// 		os.Getenv("DATABASE_USER"),
-		// 	// 		os.Getenv("DATABASE_HOST"),
-		// 	// 		os.Getenv("DATABASE_PORT"),
-		// 	// 		os.Getenv("DATABASE_NAME"),
-		// 	// 		os.Getenv("DATABASE_SSL_MODE"))
-		// }
+	for _, line := range strings.Split(string(data), "\n") {
+		if module, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+			return strings.Trim(strings.TrimSpace(module), `"`), nil
+		}
+	}
+	return "", errors.New("no module line in go.mod")
+}
 
+// templateDBType maps the database type onto the name used by the
+// migration templates, so that postgresql and mariadb share them
+func templateDBType() string {
+	switch r.DB.DataType {
+	case "postgresql", "pgx":
+		return "postgres"
+	case "mariadb":
+		return "mysql"
 	}
-	return r.BuildDSN()
+	return r.DB.DataType
 }
 
 func showHelp() {
@@ -62,14 +82,23 @@ func showHelp() {
 	help                  - show the help commands
 	version               - print application version
 	migrate               - runs all up migrations that have not been run previously
-	migrate down          - reverses the most recent migration
+	migrate down [n|all]  - reverses the most recent migration, the n most recent, or all of them
 	migrate reset         - runs all down migrations in reverse order, and then all up migrations
-	make migration <name> - creates two new up and down migrations in the migrations folder
+	migrate fresh         - drops every table (or collection), and then runs all up migrations
+	migrate status        - lists applied and pending migrations, with the current version
+	migrate to <version>  - migrates up or down to the given version
+	migrate force <ver>   - marks a version as applied without running it, to repair a dirty database
+	db schema [file]      - writes the postgres schema to schema.sql, or the given file
+	make migration <name> - creates two new up and down migrations in the migrations folder (json commands for mongodb)
+	                        add --go for a migration written in go, run through the app's cmd/migrate
 	make auth             - creates and runs migrations for authentication tables, and creates models and middleware
 	make handler <name>   - creates a stub handler in the handlers directory
 	make model <name>     - creates a new model in the data directory
 	make session          - creates a table in the database as a session store
+	make seeder <name>    - creates a sql seeder in the seeders directory (add --go for a go seeder)
+	seed                  - runs every seeder in the seeders directory, in order
 	make mail <name>      - creates two starter mail templates in the mail directory
+	make cert             - creates a self-signed localhost certificate in the tls directory
 	
 	`)
 }
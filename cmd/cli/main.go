@@ -11,6 +11,7 @@ import (
 const version = "1.0.0"
 
 var r rkt.RKT
+var cfg rkt.Config
 
 func main() {
 	var message string
@@ -42,7 +43,28 @@ func main() {
 		if err != nil {
 			exitGracefully(err)
 		}
-		message = "Migrations complete!"
+		if arg2 != "status" {
+			if cfg.Database.SchemaDump {
+				err = doSchema("")
+				if err != nil {
+					exitGracefully(err)
+				}
+			}
+			message = "Migrations complete!"
+		}
+
+	case "db":
+		err = doDB(arg2, arg3)
+		if err != nil {
+			exitGracefully(err)
+		}
+
+	case "seed":
+		err = doSeed()
+		if err != nil {
+			exitGracefully(err)
+		}
+		message = "Seeding complete!"
 
 	case "make":
 		if arg2 == "" {
@@ -89,7 +111,8 @@ func exitGracefully(err error, msg ...string) {
 	}
 
 	if err != nil {
-		color.Red("Error: %v\n", err)
+		// migrate and the drivers can echo the connection string back
+		color.Red("Error: %v\n", cfg.Database.RedactError(err))
 	}
 
 	if len(message) > 0 {
@@ -20,22 +20,37 @@ func doMake(arg2, arg3 string) error {
 		color.Yellow("32 character encryption key: %s", rnd)
 
 	case "migration":
-		dbType := r.DB.DataType
+		dbType := templateDBType()
 		if arg3 == "" {
 			exitGracefully(errors.New("you must give the migration a name"))
 		}
 
-		fileName := fmt.Sprintf("%d_%s", time.Now().UnixMicro(), arg3)
+		version := time.Now().UnixMicro()
+		fileName := fmt.Sprintf("%d_%s", version, arg3)
 
-		upFile := r.RootPath + "/migrations/" + fileName + "." + dbType + ".up.sql"
-		downFile := r.RootPath + "/migrations/" + fileName + "." + dbType + ".down.sql"
+		if hasFlag("--go") {
+			err := makeGoMigration(version, arg3)
+			if err != nil {
+				exitGracefully(err)
+			}
+			break
+		}
+
+		// mongo migrations are json arrays of database commands
+		ext := "sql"
+		if dbType == "mongodb" {
+			ext = "json"
+		}
+
+		upFile := r.RootPath + "/migrations/" + fileName + "." + dbType + ".up." + ext
+		downFile := r.RootPath + "/migrations/" + fileName + "." + dbType + ".down." + ext
 
-		err := copyFilefromTemplate("templates/migrations/migration."+dbType+".up.sql", upFile)
+		err := copyFilefromTemplate("templates/migrations/migration."+dbType+".up."+ext, upFile)
 		if err != nil {
 			exitGracefully(err)
 		}
 
-		err = copyFilefromTemplate("templates/migrations/migration."+dbType+".down.sql", downFile)
+		err = copyFilefromTemplate("templates/migrations/migration."+dbType+".down."+ext, downFile)
 		if err != nil {
 			exitGracefully(err)
 		}
@@ -106,6 +121,55 @@ func doMake(arg2, arg3 string) error {
 			exitGracefully(err)
 		}
 
+		err = ensureModelsFile()
+		if err != nil {
+			exitGracefully(err)
+		}
+
+		err = addModelField(strcase.ToCamel(tableName), strcase.ToCamel(modelName))
+		if err != nil {
+			exitGracefully(err)
+		}
+
+	case "seeder":
+		if arg3 == "" {
+			exitGracefully(errors.New("you must give the seeder a name"))
+		}
+
+		err := r.CreateDirIfNotExist(r.RootPath + "/seeders")
+		if err != nil {
+			exitGracefully(err)
+		}
+
+		name := fmt.Sprintf("%d_%s", time.Now().UnixMicro(), strcase.ToSnake(arg3))
+
+		// mongodb has no sql, so its seeders are always written in go
+		if !hasFlag("--go") && templateDBType() != "mongodb" {
+			err = copyFilefromTemplate("templates/seeders/seeder.sql.txt", r.RootPath+"/seeders/"+name+".sql")
+			if err != nil {
+				exitGracefully(err)
+			}
+			break
+		}
+
+		data, err := templateFS.ReadFile("templates/seeders/seeder.go.txt")
+		if err != nil {
+			exitGracefully(err)
+		}
+
+		seeder := strings.ReplaceAll(string(data), "$SEEDERNAME$", name)
+		err = copyDataToFile([]byte(seeder), r.RootPath+"/seeders/"+name+".go")
+		if err != nil {
+			exitGracefully(err)
+		}
+
+		if !fileExists(r.RootPath + "/seeders/main.go") {
+			err = copyFilefromTemplate("templates/seeders/main.go.txt", r.RootPath+"/seeders/main.go")
+			if err != nil {
+				exitGracefully(err)
+			}
+		}
+
 	case "mail":
 		if arg3 == "" {
 			exitGracefully(errors.New("you must give the mail template a name"))
@@ -128,7 +192,70 @@ func doMake(arg2, arg3 string) error {
 		if err != nil {
 			exitGracefully(err)
 		}
+
+	case "cert":
+		err := doCert()
+		if err != nil {
+			exitGracefully(err)
+		}
 	}
 
 	return nil
 }
+
+// makeGoMigration writes a Go migration to the migrations package, and the
+// app's migrate command that runs it the first time one is made
+func makeGoMigration(version int64, name string) error {
+	if templateDBType() == "mongodb" {
+		return errors.New("go migrations need a sql database")
+	}
+
+	data, err := templateFS.ReadFile("templates/migrations/migration.go.txt")
+	if err != nil {
+		return err
+	}
+
+	migration := strings.ReplaceAll(string(data), "$VERSION$", fmt.Sprint(version))
+	migration = strings.ReplaceAll(migration, "$NAME$", name)
+
+	fileName := fmt.Sprintf("%s/migrations/%d_%s.go", r.RootPath, version, name)
+	err = copyDataToFile([]byte(migration), fileName)
+	if err != nil {
+		return err
+	}
+
+	// the set the migrations register in
+	setFile := r.RootPath + "/migrations/migrations.go"
+	if !fileExists(setFile) {
+		data, err = templateFS.ReadFile("templates/migrations/migrations.go.txt")
+		if err != nil {
+			return err
+		}
+		err = copyDataToFile(data, setFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	mainFile := r.RootPath + "/cmd/migrate/main.go"
+	if fileExists(mainFile) {
+		return nil
+	}
+
+	module, err := appModule()
+	if err != nil {
+		return err
+	}
+
+	data, err = templateFS.ReadFile("templates/migrations/main.go.txt")
+	if err != nil {
+		return err
+	}
+
+	err = r.CreateDirIfNotExist(r.RootPath + "/cmd/migrate")
+	if err != nil {
+		return err
+	}
+
+	return copyDataToFile([]byte(strings.ReplaceAll(string(data), "$APPMODULE$", module)), mainFile)
+}
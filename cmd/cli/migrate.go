@@ -1,40 +1,28 @@
 package main
 
-func doMigrate(arg2, arg3 string) error {
-	dsn := getDSN()
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
 
-	// run the migration command
+func doMigrate(arg2, arg3 string) error {
 	switch arg2 {
-	case "up":
-		err := r.MigrateUp(dsn)
-		if err != nil {
-			return err
-		}
-
-	case "down":
-		if arg3 == "all" {
-			err := r.MigrateDownAll(dsn)
-			if err != nil {
-				return err
-			}
-		} else {
-			err := r.Steps(-1, dsn)
-			if err != nil {
-				return err
-			}
-		}
-	case "reset":
-		err := r.MigrateDownAll(dsn)
-		if err != nil {
-			return err
-		}
-		err = r.MigrateUp(dsn)
-		if err != nil {
-			return err
-		}
+	case "up", "down", "to", "force", "fresh", "reset", "status":
 	default:
 		showHelp()
+		return errors.New("unknown migrate command " + arg2)
+	}
+
+	// Go migrations are compiled into the app's migrate command, so it runs
+	// the migrations instead of the cli
+	if fileExists(r.RootPath + "/cmd/migrate/main.go") {
+		cmd := exec.Command("go", "run", "./cmd/migrate", arg2, arg3)
+		cmd.Dir = r.RootPath
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		return cmd.Run()
 	}
 
-	return nil
+	return r.MigrateCommand(os.Stdout, getDSN(), arg2, arg3)
 }
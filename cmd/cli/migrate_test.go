@@ -0,0 +1,10 @@
+package main
+
+import "testing"
+
+func TestDoMigrate_UnknownCommand(t *testing.T) {
+	// main only dumps the schema and reports success when doMigrate doesn't fail
+	if err := doMigrate("foo", ""); err == nil {
+		t.Error("expected an unknown migrate command to fail")
+	}
+}
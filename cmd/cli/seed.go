@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+
+	"github.com/fatih/color"
+	"github.com/m-goku/rkt"
+	"github.com/m-goku/rkt/seed"
+)
+
+func doSeed() error {
+	dir := r.RootPath + "/seeders"
+	if !fileExists(dir) {
+		color.Yellow("No seeders yet; create one with rkt make seeder <name>")
+		return nil
+	}
+
+	// go seeders are compiled into the app's seeders command, which runs the
+	// sql seeders as well so that everything runs in one order
+	if fileExists(dir + "/main.go") {
+		cmd := exec.Command("go", "run", "./seeders")
+		cmd.Dir = r.RootPath
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		return cmd.Run()
+	}
+
+	db, err := r.OpenDB(cfg.Database.Type, cfg.Database.BuildDSN())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	r.DB = rkt.Database{DataType: cfg.Database.Type, Pool: db}
+
+	ran, err := seed.Run(context.Background(), &r, dir)
+	for _, name := range ran {
+		color.Green("  - seeded %s", name)
+	}
+	return err
+}
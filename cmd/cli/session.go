@@ -6,27 +6,25 @@ import (
 )
 
 func doSessionTable() error {
-	dbType := r.DB.DataType
+	dbType := templateDBType()
 
-	if dbType == "mariadb" {
-		dbType = "mysql"
-	}
+	fileName := fmt.Sprintf("%d_create_sessions_table", time.Now().UnixMicro())
 
-	if dbType == "postgresql" {
-		dbType = "postgres"
+	// mongodb gets a collection with a TTL index instead of a table
+	ext, down := "sql", "drop table sessions"
+	if dbType == "mongodb" {
+		ext, down = "json", `[{"drop": "sessions"}]`
 	}
 
-	fileName := fmt.Sprintf("%d_create_sessions_table", time.Now().UnixMicro())
-
-	upFile := r.RootPath + "/migrations/" + fileName + "." + dbType + ".up.sql"
-	downFile := r.RootPath + "/migrations/" + fileName + "." + dbType + ".down.sql"
+	upFile := r.RootPath + "/migrations/" + fileName + "." + dbType + ".up." + ext
+	downFile := r.RootPath + "/migrations/" + fileName + "." + dbType + ".down." + ext
 
-	err := copyFilefromTemplate("templates/migrations/"+dbType+"_session.sql", upFile)
+	err := copyFilefromTemplate("templates/migrations/"+dbType+"_session."+ext, upFile)
 	if err != nil {
 		exitGracefully(err)
 	}
 
-	err = copyDataToFile([]byte("drop table sessions"), downFile)
+	err = copyDataToFile([]byte(down), downFile)
 	if err != nil {
 		exitGracefully(err)
 	}
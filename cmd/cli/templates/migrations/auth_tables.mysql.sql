@@ -0,0 +1,36 @@
+drop table if exists remember_tokens;
+drop table if exists tokens;
+drop table if exists users;
+
+CREATE TABLE users (
+    id int unsigned NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    first_name varchar(255) NOT NULL,
+    last_name varchar(255) NOT NULL,
+    user_active int NOT NULL DEFAULT 0,
+    email varchar(255) NOT NULL UNIQUE,
+    password char(60) NOT NULL,
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE remember_tokens (
+    id int unsigned NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    user_id int unsigned NOT NULL,
+    remember_token varchar(100) NOT NULL,
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    CONSTRAINT remember_tokens_user_id_fk FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE tokens (
+    id int unsigned NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    user_id int unsigned NOT NULL,
+    first_name varchar(255) NOT NULL,
+    email varchar(255) NOT NULL,
+    token varchar(255) NOT NULL,
+    token_hash varbinary(255) NOT NULL,
+    created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    expiry datetime NOT NULL,
+    CONSTRAINT tokens_user_id_fk FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE ON UPDATE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,55 @@
+drop table if exists remember_tokens;
+drop table if exists tokens;
+drop table if exists users;
+
+CREATE TABLE users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    first_name TEXT NOT NULL,
+    last_name TEXT NOT NULL,
+    user_active INTEGER NOT NULL DEFAULT 0,
+    email TEXT NOT NULL UNIQUE,
+    password TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER users_set_timestamp
+    AFTER UPDATE ON users
+    FOR EACH ROW
+    BEGIN
+        UPDATE users SET updated_at = CURRENT_TIMESTAMP WHERE id = OLD.id;
+    END;
+
+CREATE TABLE remember_tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    remember_token TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER remember_tokens_set_timestamp
+    AFTER UPDATE ON remember_tokens
+    FOR EACH ROW
+    BEGIN
+        UPDATE remember_tokens SET updated_at = CURRENT_TIMESTAMP WHERE id = OLD.id;
+    END;
+
+CREATE TABLE tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    first_name TEXT NOT NULL,
+    email TEXT NOT NULL,
+    token TEXT NOT NULL,
+    token_hash BLOB NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expiry DATETIME NOT NULL
+);
+
+CREATE TRIGGER tokens_set_timestamp
+    AFTER UPDATE ON tokens
+    FOR EACH ROW
+    BEGIN
+        UPDATE tokens SET updated_at = CURRENT_TIMESTAMP WHERE id = OLD.id;
+    END;
@@ -0,0 +1,14 @@
+CREATE TABLE some_table (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    some_field TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- keep updated_at current; sqlite has no ON UPDATE clause
+CREATE TRIGGER some_table_set_timestamp
+    AFTER UPDATE ON some_table
+    FOR EACH ROW
+    BEGIN
+        UPDATE some_table SET updated_at = CURRENT_TIMESTAMP WHERE id = OLD.id;
+    END;
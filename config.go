@@ -0,0 +1,501 @@
+package rkt
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// configFiles are looked up in the project root, in this order. JSON is
+// read with the yaml decoder, since yaml is a superset of json.
+var configFiles = []string{"rkt.yaml", "rkt.yml", "rkt.toml", "rkt.json"}
+
+/*
+Config holds every setting needed to boot an RKT instance. It can be built
+in code and passed to NewWithConfig, or loaded from a file in the project
+root with LoadConfig. Environment variables always win over the file.
+*/
+type Config struct {
+	AppName  string         `yaml:"app_name" toml:"app_name"`
+	Debug    bool           `yaml:"debug" toml:"debug"`
+	Key      string         `yaml:"key" toml:"key"`
+	Server   ServerConfig   `yaml:"server" toml:"server"`
+	Database DatabaseConfig `yaml:"database" toml:"database"`
+	Cache    CacheConfig    `yaml:"cache" toml:"cache"`
+	Session  SessionConfig  `yaml:"session" toml:"session"`
+	Cookie   CookieConfig   `yaml:"cookie" toml:"cookie"`
+	Mail     MailConfig     `yaml:"mail" toml:"mail"`
+	Renderer RendererConfig `yaml:"renderer" toml:"renderer"`
+	Log      LogConfig      `yaml:"log" toml:"log"`
+	Health   HealthConfig   `yaml:"health" toml:"health"`
+	Metrics  MetricsConfig  `yaml:"metrics" toml:"metrics"`
+
+	// envProblems holds the environment variables ApplyEnv couldn't parse,
+	// for Validate to report
+	envProblems []string
+}
+
+type ServerConfig struct {
+	Name            string        `yaml:"name" toml:"name"`
+	Port            string        `yaml:"port" toml:"port"`
+	URL             string        `yaml:"url" toml:"url"`
+	Secure          bool          `yaml:"secure" toml:"secure"`
+	TLSCertFile     string        `yaml:"tls_cert_file" toml:"tls_cert_file"`
+	TLSKeyFile      string        `yaml:"tls_key_file" toml:"tls_key_file"`
+	RedirectPort    string        `yaml:"redirect_port" toml:"redirect_port"`
+	HSTSMaxAge      int           `yaml:"hsts_max_age" toml:"hsts_max_age"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+}
+
+// defaultSQLiteFile is the database file used when sqlite has no DSN
+const defaultSQLiteFile = "db.sqlite"
+
+// DatabaseConfig describes the database connection. DSN is the full
+// connection string; when it is empty, BuildDSN assembles one from Host,
+// Port, User, Password, Name and SSLMode.
+type DatabaseConfig struct {
+	Type     string     `yaml:"type" toml:"type"`
+	DSN      string     `yaml:"dsn" toml:"dsn"`
+	Host     string     `yaml:"host" toml:"host"`
+	Port     string     `yaml:"port" toml:"port"`
+	User     string     `yaml:"user" toml:"user"`
+	Password string     `yaml:"password" toml:"password"`
+	Name     string     `yaml:"name" toml:"name"`         // database name, or the file for sqlite
+	SSLMode  string     `yaml:"ssl_mode" toml:"ssl_mode"` // postgres sslmode values; mapped onto mysql tls and mongodb tls
+	Replicas []string   `yaml:"replicas" toml:"replicas"` // read replica connection strings
+	Pool     PoolConfig `yaml:"pool" toml:"pool"`         // shared by the primary and each replica
+
+	// MigrateOnStart applies pending migrations in New, before anything
+	// else uses the database
+	MigrateOnStart bool `yaml:"migrate_on_start" toml:"migrate_on_start"`
+
+	// SchemaDump makes rkt migrate write schema.sql after each run, as rkt
+	// db schema does. Postgres only.
+	SchemaDump bool `yaml:"schema_dump" toml:"schema_dump"`
+}
+
+// PoolConfig sizes the database connection pool. A zero value keeps the
+// default: sqlPoolDefaults for sql databases and the driver's own for mongo.
+// MaxOpen is also the mongo pool size and MaxIdleTime its idle timeout;
+// mongo has no idle count or lifetime limit.
+type PoolConfig struct {
+	MaxOpen     int           `yaml:"max_open" toml:"max_open"`
+	MaxIdle     int           `yaml:"max_idle" toml:"max_idle"`
+	MaxLifetime time.Duration `yaml:"max_lifetime" toml:"max_lifetime"`
+	MaxIdleTime time.Duration `yaml:"max_idle_time" toml:"max_idle_time"`
+}
+
+type CacheConfig struct {
+	Type  string      `yaml:"type" toml:"type"`
+	Redis RedisConfig `yaml:"redis" toml:"redis"`
+}
+
+type RedisConfig struct {
+	Host     string `yaml:"host" toml:"host"`
+	Password string `yaml:"password" toml:"password"`
+	Prefix   string `yaml:"prefix" toml:"prefix"`
+}
+
+type SessionConfig struct {
+	Type string `yaml:"type" toml:"type"`
+}
+
+type CookieConfig struct {
+	Name     string `yaml:"name" toml:"name"`
+	Lifetime int    `yaml:"lifetime" toml:"lifetime"` // minutes
+	Persist  bool   `yaml:"persist" toml:"persist"`
+	Secure   bool   `yaml:"secure" toml:"secure"`
+	Domain   string `yaml:"domain" toml:"domain"`
+}
+
+type MailConfig struct {
+	FromName    string `yaml:"from_name" toml:"from_name"`
+	FromAddress string `yaml:"from_address" toml:"from_address"`
+	PublicAPI   string `yaml:"public_api" toml:"public_api"`
+	PrivateAPI  string `yaml:"private_api" toml:"private_api"`
+}
+
+type RendererConfig struct {
+	Engine string `yaml:"engine" toml:"engine"`
+}
+
+type LogConfig struct {
+	Level      string `yaml:"level" toml:"level"`             // debug, info, warn or error
+	Format     string `yaml:"format" toml:"format"`           // text or json
+	File       bool   `yaml:"file" toml:"file"`               // also write to logs/<app name>.log
+	MaxSize    int    `yaml:"max_size" toml:"max_size"`       // megabytes before the file is rotated
+	MaxBackups int    `yaml:"max_backups" toml:"max_backups"` // rotated files to keep, 0 keeps all
+	MaxAge     int    `yaml:"max_age" toml:"max_age"`         // days to keep rotated files, 0 keeps all
+}
+
+type HealthConfig struct {
+	Enabled       bool          `yaml:"enabled" toml:"enabled"`
+	LivenessPath  string        `yaml:"liveness_path" toml:"liveness_path"`
+	ReadinessPath string        `yaml:"readiness_path" toml:"readiness_path"`
+	Timeout       time.Duration `yaml:"timeout" toml:"timeout"` // per readiness probe
+}
+
+type MetricsConfig struct {
+	Enabled bool   `yaml:"enabled" toml:"enabled"`
+	Path    string `yaml:"path" toml:"path"`
+}
+
+// DefaultConfig returns the settings used when neither a config file nor
+// the environment says otherwise
+func DefaultConfig() Config {
+	return Config{
+		Server: ServerConfig{
+			Secure:          true,
+			HSTSMaxAge:      31536000, // one year, the minimum accepted for preload lists
+			ShutdownTimeout: 30 * time.Second,
+		},
+		Cookie: CookieConfig{
+			Lifetime: 60,
+		},
+		Log: LogConfig{
+			Format:     "text",
+			MaxSize:    100,
+			MaxBackups: 7,
+		},
+		Health: HealthConfig{
+			Enabled:       true,
+			LivenessPath:  "/healthz",
+			ReadinessPath: "/readyz",
+			Timeout:       2 * time.Second,
+		},
+		Metrics: MetricsConfig{
+			Path: "/metrics",
+		},
+	}
+}
+
+// LoadConfig starts from DefaultConfig, reads rkt.yaml, rkt.yml, rkt.toml or
+// rkt.json from rootPath if one exists, and then overlays the environment
+func LoadConfig(rootPath string) (Config, error) {
+	cfg := DefaultConfig()
+
+	for _, name := range configFiles {
+		path := filepath.Join(rootPath, name)
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return cfg, err
+		}
+
+		if filepath.Ext(name) == ".toml" {
+			md, err := toml.Decode(string(data), &cfg)
+			if err != nil {
+				return cfg, fmt.Errorf("%s: %w", name, err)
+			}
+			if undecoded := md.Undecoded(); len(undecoded) > 0 {
+				return cfg, fmt.Errorf("%s: unknown setting %s", name, undecoded[0])
+			}
+		} else {
+			dec := yaml.NewDecoder(bytes.NewReader(data))
+			dec.KnownFields(true)
+			if err := dec.Decode(&cfg); err != nil {
+				return cfg, fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		break
+	}
+
+	cfg.ApplyEnv()
+	return cfg, nil
+}
+
+// ApplyEnv overwrites settings with the environment variables that are set.
+// Empty variables are ignored so a blank line in .env doesn't wipe a value
+// coming from the config file. Values that don't parse keep the setting as
+// it was, and are reported by Validate.
+func (cfg *Config) ApplyEnv() {
+	env := &envReader{}
+
+	env.string("APP_NAME", &cfg.AppName)
+	env.bool("DEBUG", &cfg.Debug)
+	env.string("KEY", &cfg.Key)
+
+	env.string("SERVER_NAME", &cfg.Server.Name)
+	env.string("PORT", &cfg.Server.Port)
+	env.string("APP_URL", &cfg.Server.URL)
+	env.bool("SECURE", &cfg.Server.Secure)
+	env.string("TLS_CERT_FILE", &cfg.Server.TLSCertFile)
+	env.string("TLS_KEY_FILE", &cfg.Server.TLSKeyFile)
+	env.string("HTTP_REDIRECT_PORT", &cfg.Server.RedirectPort)
+	env.int("HSTS_MAX_AGE", &cfg.Server.HSTSMaxAge)
+	env.seconds("SHUTDOWN_TIMEOUT", &cfg.Server.ShutdownTimeout)
+
+	env.string("DATABASE_TYPE", &cfg.Database.Type)
+	env.string("DATABASE_CONN_STR", &cfg.Database.DSN)
+	env.string("DATABASE_HOST", &cfg.Database.Host)
+	env.string("DATABASE_PORT", &cfg.Database.Port)
+	env.string("DATABASE_USER", &cfg.Database.User)
+	env.string("DATABASE_PASS", &cfg.Database.Password)
+	env.string("DATABASE_NAME", &cfg.Database.Name)
+	env.string("DATABASE_SSL_MODE", &cfg.Database.SSLMode)
+	env.list("DATABASE_REPLICAS", &cfg.Database.Replicas)
+	env.int("DATABASE_MAX_OPEN_CONNS", &cfg.Database.Pool.MaxOpen)
+	env.int("DATABASE_MAX_IDLE_CONNS", &cfg.Database.Pool.MaxIdle)
+	env.seconds("DATABASE_CONN_MAX_LIFETIME", &cfg.Database.Pool.MaxLifetime)
+	env.seconds("DATABASE_CONN_MAX_IDLE_TIME", &cfg.Database.Pool.MaxIdleTime)
+	env.bool("MIGRATE_ON_START", &cfg.Database.MigrateOnStart)
+	env.bool("DATABASE_SCHEMA_DUMP", &cfg.Database.SchemaDump)
+
+	env.string("CACHE", &cfg.Cache.Type)
+	env.string("REDIS_HOST", &cfg.Cache.Redis.Host)
+	env.string("REDIS_PASSWORD", &cfg.Cache.Redis.Password)
+	env.string("REDIS_PREFIX", &cfg.Cache.Redis.Prefix)
+
+	env.string("SESSION_TYPE", &cfg.Session.Type)
+
+	env.string("COOKIE_NAME", &cfg.Cookie.Name)
+	env.int("COOKIE_LIFETIME", &cfg.Cookie.Lifetime)
+	// COOKIE_PERSISTS is the spelling older versions read
+	env.bool("COOKIE_PERSISTS", &cfg.Cookie.Persist)
+	env.bool("COOKIE_PERSIST", &cfg.Cookie.Persist)
+	env.bool("COOKIE_SECURE", &cfg.Cookie.Secure)
+	env.string("COOKIE_DOMAIN", &cfg.Cookie.Domain)
+
+	env.string("FROM_NAME", &cfg.Mail.FromName)
+	env.string("FROM_ADDRESS", &cfg.Mail.FromAddress)
+	env.string("PUBLIC_API", &cfg.Mail.PublicAPI)
+	env.string("PRIVATE_API", &cfg.Mail.PrivateAPI)
+
+	env.string("RENDERER", &cfg.Renderer.Engine)
+
+	env.string("LOG_LEVEL", &cfg.Log.Level)
+	env.string("LOG_FORMAT", &cfg.Log.Format)
+	env.bool("LOG_FILE", &cfg.Log.File)
+	env.int("LOG_MAX_SIZE", &cfg.Log.MaxSize)
+	env.int("LOG_MAX_BACKUPS", &cfg.Log.MaxBackups)
+	env.int("LOG_MAX_AGE", &cfg.Log.MaxAge)
+
+	env.bool("HEALTH_ENABLED", &cfg.Health.Enabled)
+	env.string("HEALTH_LIVENESS_PATH", &cfg.Health.LivenessPath)
+	env.string("HEALTH_READINESS_PATH", &cfg.Health.ReadinessPath)
+	env.seconds("HEALTH_TIMEOUT", &cfg.Health.Timeout)
+
+	env.bool("METRICS_ENABLED", &cfg.Metrics.Enabled)
+	env.string("METRICS_PATH", &cfg.Metrics.Path)
+
+	cfg.envProblems = env.problems
+}
+
+// envReader overlays environment variables onto settings, noting the ones
+// it can't parse
+type envReader struct {
+	problems []string
+}
+
+func (e *envReader) string(key string, target *string) {
+	if v := os.Getenv(key); v != "" {
+		*target = v
+	}
+}
+
+func (e *envReader) bool(key string, target *bool) {
+	v := os.Getenv(key)
+	if v == "" {
+		return
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		e.problems = append(e.problems, fmt.Sprintf("%s: %q is not true or false", key, v))
+		return
+	}
+	*target = b
+}
+
+func (e *envReader) int(key string, target *int) {
+	v := os.Getenv(key)
+	if v == "" {
+		return
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		e.problems = append(e.problems, fmt.Sprintf("%s: %q is not a whole number", key, v))
+		return
+	}
+	*target = n
+}
+
+// list reads a comma separated list
+func (e *envReader) list(key string, target *[]string) {
+	v := os.Getenv(key)
+	if v == "" {
+		return
+	}
+
+	var list []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	*target = list
+}
+
+// seconds reads a whole number of seconds into a duration
+func (e *envReader) seconds(key string, target *time.Duration) {
+	v := os.Getenv(key)
+	if v == "" {
+		return
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		e.problems = append(e.problems, fmt.Sprintf("%s: %q is not a whole number of seconds", key, v))
+		return
+	}
+	*target = time.Duration(n) * time.Second
+}
+
+// ConfigError lists every problem found while validating a Config or
+// connecting to the backends it describes
+type ConfigError struct {
+	Problems []string
+}
+
+func (e *ConfigError) Error() string {
+	return "rkt: invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Validate checks the whole configuration and returns a *ConfigError
+// listing every problem, or nil if there are none
+func (cfg Config) Validate() error {
+	return cfg.validate(false)
+}
+
+// validate is Validate for NewWithConfig. pooled is set when the app was
+// handed an open database pool, which then needs no connection string.
+func (cfg Config) validate(pooled bool) error {
+	problems := append([]string(nil), cfg.envProblems...)
+
+	switch {
+	case cfg.Key == "":
+		problems = append(problems, "KEY: encryption key is not set (generate one with rkt make key)")
+	case len(cfg.Key) != 32:
+		problems = append(problems, fmt.Sprintf("KEY: encryption key must be exactly 32 characters long, got %d", len(cfg.Key)))
+	}
+
+	switch cfg.Database.Type {
+	case "":
+	case "postgres", "postgresql", "mysql", "mariadb", "mongodb":
+		if cfg.Database.BuildDSN() == "" && !pooled {
+			problems = append(problems, fmt.Sprintf("DATABASE_CONN_STR: no connection string, or DATABASE_HOST and DATABASE_NAME, for database type %s", cfg.Database.Type))
+		}
+	case "sqlite":
+		// an empty DSN means db.sqlite in the project root
+	default:
+		problems = append(problems, fmt.Sprintf("DATABASE_TYPE: unsupported database type %q", cfg.Database.Type))
+	}
+
+	if cfg.Database.MigrateOnStart && cfg.Database.Type == "" {
+		problems = append(problems, "MIGRATE_ON_START: migrations need a DATABASE_TYPE")
+	}
+
+	if cfg.Database.SchemaDump && sqlFamily(cfg.Database.Type) != "postgres" {
+		problems = append(problems, fmt.Sprintf("DATABASE_SCHEMA_DUMP: schema dumps need a postgres database, got %q", cfg.Database.Type))
+	}
+
+	if len(cfg.Database.Replicas) > 0 {
+		switch sqlFamily(cfg.Database.Type) {
+		case "postgres", "mysql":
+		default:
+			problems = append(problems, fmt.Sprintf("DATABASE_REPLICAS: read replicas need a postgres or mysql database, got %q", cfg.Database.Type))
+		}
+	}
+
+	pool := cfg.Database.Pool
+	if pool.MaxOpen < 0 || pool.MaxIdle < 0 || pool.MaxLifetime < 0 || pool.MaxIdleTime < 0 {
+		problems = append(problems, "DATABASE_MAX_*: pool settings can't be negative")
+	}
+	if pool.MaxOpen > 0 && pool.MaxIdle > pool.MaxOpen {
+		problems = append(problems, fmt.Sprintf("DATABASE_MAX_IDLE_CONNS: %d idle connections is more than the %d open allowed", pool.MaxIdle, pool.MaxOpen))
+	}
+
+	switch cfg.Cache.Type {
+	case "", "badger", "memory":
+	case "redis":
+		if cfg.Cache.Redis.Host == "" {
+			problems = append(problems, "REDIS_HOST: CACHE=redis needs a redis host")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("CACHE: unsupported cache type %q", cfg.Cache.Type))
+	}
+
+	switch cfg.Session.Type {
+	case "", "cookie":
+	case "redis":
+		if cfg.Cache.Redis.Host == "" {
+			problems = append(problems, "REDIS_HOST: SESSION_TYPE=redis needs a redis host")
+		}
+	case "postgres", "postgresql", "mysql", "mariadb", "sqlite":
+		if sqlFamily(cfg.Session.Type) != sqlFamily(cfg.Database.Type) {
+			problems = append(problems, fmt.Sprintf("SESSION_TYPE: %s sessions need DATABASE_TYPE %s, got %q", cfg.Session.Type, cfg.Session.Type, cfg.Database.Type))
+		}
+	case "mongodb":
+		switch {
+		case cfg.Database.Type != "mongodb":
+			problems = append(problems, fmt.Sprintf("SESSION_TYPE: mongodb sessions need DATABASE_TYPE mongodb, got %q", cfg.Database.Type))
+		case cfg.Database.MongoDatabase() == "":
+			problems = append(problems, "DATABASE_CONN_STR: mongodb sessions need a database name in the connection string")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("SESSION_TYPE: unsupported session store %q", cfg.Session.Type))
+	}
+
+	switch cfg.Renderer.Engine {
+	case "", "go", "jet":
+	default:
+		problems = append(problems, fmt.Sprintf("RENDERER: unsupported template engine %q", cfg.Renderer.Engine))
+	}
+
+	switch strings.ToLower(cfg.Log.Level) {
+	case "", "debug", "info", "warn", "error":
+	default:
+		problems = append(problems, fmt.Sprintf("LOG_LEVEL: unknown level %q, use debug, info, warn or error", cfg.Log.Level))
+	}
+
+	switch strings.ToLower(cfg.Log.Format) {
+	case "", "text", "json":
+	default:
+		problems = append(problems, fmt.Sprintf("LOG_FORMAT: unknown format %q, use text or json", cfg.Log.Format))
+	}
+
+	if cfg.Health.Enabled && cfg.Health.Timeout <= 0 {
+		problems = append(problems, "HEALTH_TIMEOUT: must be a positive number of seconds")
+	}
+
+	if cfg.Cookie.Lifetime <= 0 {
+		problems = append(problems, "COOKIE_LIFETIME: must be a positive number of minutes")
+	}
+
+	if cfg.Server.Secure && (cfg.Server.TLSCertFile == "") != (cfg.Server.TLSKeyFile == "") {
+		problems = append(problems, "TLS_CERT_FILE, TLS_KEY_FILE: both must be set to serve https")
+	}
+
+	if len(problems) > 0 {
+		return &ConfigError{Problems: problems}
+	}
+	return nil
+}
+
+// sqlFamily maps database type aliases onto the driver family they use
+func sqlFamily(dbType string) string {
+	switch dbType {
+	case "postgres", "postgresql", "pgx":
+		return "postgres"
+	case "mysql", "mariadb":
+		return "mysql"
+	}
+	return dbType
+}
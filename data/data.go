@@ -0,0 +1,140 @@
+/*
+Package data opens an upper/db session on the database connected by rkt and
+keeps a registry of the app's models. The models generated by rkt make model
+and rkt make auth are written against it:
+
+	models, err := data.New(app.DB)  // the app's own data package
+	user, err := models.Get("users")
+
+Postgres, mysql, mariadb and sqlite are supported; mongodb apps use
+app.DB.Conn directly.
+*/
+package data
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/m-goku/rkt"
+	up "github.com/upper/db/v4"
+	"github.com/upper/db/v4/adapter/mysql"
+	"github.com/upper/db/v4/adapter/postgresql"
+	"github.com/upper/db/v4/adapter/sqlite"
+)
+
+// ErrUnsupported is returned by Open for databases upper/db can't talk to
+var ErrUnsupported = errors.New("data: database type is not supported by upper/db")
+
+// Model is anything stored in a table
+type Model interface {
+	Table() string
+}
+
+// Models is a registry of models, keyed by table, sharing one upper session
+type Models struct {
+	Session up.Session
+
+	mu     sync.RWMutex
+	models map[string]Model
+}
+
+// Open returns an upper/db session on the primary of db. The session shares
+// db's pool, which stays owned by rkt: close the app, not the session.
+func Open(db rkt.Database) (up.Session, error) {
+	if db.Pool == nil {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupported, db.DataType)
+	}
+
+	switch db.DataType {
+	case "postgres", "postgresql", "pgx":
+		return postgresql.New(db.Pool)
+	case "mysql", "mariadb":
+		return mysql.New(db.Pool)
+	case "sqlite":
+		return sqlite.New(db.Pool)
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrUnsupported, db.DataType)
+}
+
+// New opens a session on db and registers models
+func New(db rkt.Database, models ...Model) (*Models, error) {
+	sess, err := Open(db)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Models{
+		Session: sess,
+		models:  make(map[string]Model),
+	}
+	for _, model := range models {
+		if err := m.Register(model); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// Register adds a model to the registry. A table can only be registered once.
+func (m *Models) Register(model Model) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	table := model.Table()
+	if _, ok := m.models[table]; ok {
+		return fmt.Errorf("data: a model for table %s is already registered", table)
+	}
+	m.models[table] = model
+	return nil
+}
+
+// Get returns the model registered for table
+func (m *Models) Get(table string) (Model, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	model, ok := m.models[table]
+	return model, ok
+}
+
+// Tables returns the tables of every registered model, sorted
+func (m *Models) Tables() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tables := make([]string, 0, len(m.models))
+	for table := range m.models {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	return tables
+}
+
+// Collection returns the upper collection for a model's table
+func (m *Models) Collection(model Model) up.Collection {
+	return m.Session.Collection(model.Table())
+}
+
+// InsertID turns the id returned by an insert into an int. Postgres returns
+// int64, while some drivers return other integer types.
+func InsertID(i up.ID) int {
+	switch id := i.(type) {
+	case int64:
+		return int(id)
+	case int32:
+		return int(id)
+	case int:
+		return id
+	case uint64:
+		return int(id)
+	case []uint8:
+		var n int
+		_, _ = fmt.Sscan(string(id), &n)
+		return n
+	}
+	return 0
+}
@@ -0,0 +1,70 @@
+package data
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/m-goku/rkt"
+	_ "modernc.org/sqlite"
+)
+
+type widget struct {
+	ID        int       `db:"id,omitempty"`
+	Name      string    `db:"name"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func (w *widget) Table() string {
+	return "widgets"
+}
+
+func TestModels(t *testing.T) {
+	pool, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "data.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Close()
+
+	_, err = pool.Exec("create table widgets (id integer primary key autoincrement, name text not null, created_at datetime not null)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	models, err := New(rkt.Database{DataType: "sqlite", Pool: pool}, &widget{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := models.Get("widgets"); !ok {
+		t.Error("expected widgets to be registered")
+	}
+	if err := models.Register(&widget{}); err == nil {
+		t.Error("expected registering a table twice to fail")
+	}
+
+	res, err := models.Collection(&widget{}).Insert(widget{Name: "sprocket", CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := InsertID(res.ID()); id != 1 {
+		t.Errorf("expected insert id 1, got %d", id)
+	}
+
+	var w widget
+	if err := models.Collection(&w).Find(1).One(&w); err != nil {
+		t.Fatal(err)
+	}
+	if w.Name != "sprocket" {
+		t.Errorf("expected sprocket, got %q", w.Name)
+	}
+}
+
+func TestOpen_Unsupported(t *testing.T) {
+	_, err := Open(rkt.Database{DataType: "mongodb"})
+	if !errors.Is(err, ErrUnsupported) {
+		t.Errorf("expected ErrUnsupported, got %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package rkt
 
 import (
 	"database/sql"
+	"strings"
 
 	"context"
 	"time"
@@ -9,30 +10,61 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
+	_ "github.com/go-sql-driver/mysql"
 	_ "github.com/jackc/pgconn"
 	_ "github.com/jackc/pgx/v4"
 	_ "github.com/jackc/pgx/v4/stdlib"
+	_ "modernc.org/sqlite"
 )
 
-// OpenDB opens a connection to a sql database. dbType must be one of postgres (or pgx).
-// TODO: add support for mysql/mariadb
-func (c *RKT) OpenPostgresDB(dbType, dsn string) (*sql.DB, error) {
-	if dbType == "postgres" || dbType == "postgresql" {
-		dbType = "pgx"
+// OpenDB opens a connection to a sql database. dbType must be one of
+// postgres, postgresql, pgx, mysql, mariadb or sqlite.
+func (c *RKT) OpenDB(dbType, dsn string) (*sql.DB, error) {
+	db, err := c.openPool(dbType, dsn)
+	if err != nil {
+		return nil, err
 	}
 
-	db, err := sql.Open(dbType, dsn)
+	err = db.Ping()
 	if err != nil {
+		_ = db.Close()
 		return nil, err
 	}
 
-	err = db.Ping()
+	return db, nil
+
+}
+
+// openPool opens a sql pool with the configured limits, without connecting
+func (c *RKT) openPool(dbType, dsn string) (*sql.DB, error) {
+	switch dbType {
+	case "postgres", "postgresql":
+		dbType = "pgx"
+	case "mariadb":
+		dbType = "mysql"
+	}
+
+	db, err := sql.Open(dbType, dsn)
 	if err != nil {
 		return nil, err
 	}
+	c.config.Database.Pool.applySQL(db)
+
+	// every connection to an in-memory sqlite database gets its own empty
+	// database, so the pool must hold on to exactly one
+	if dbType == "sqlite" && (strings.HasPrefix(dsn, ":memory:") || strings.Contains(dsn, "mode=memory")) {
+		db.SetMaxOpenConns(1)
+		db.SetConnMaxIdleTime(0)
+		db.SetConnMaxLifetime(0)
+	}
 
 	return db, nil
+}
 
+// OpenPostgresDB opens a connection to a postgres database. It is kept for
+// existing callers; new code should use OpenDB.
+func (c *RKT) OpenPostgresDB(dbType, dsn string) (*sql.DB, error) {
+	return c.OpenDB(dbType, dsn)
 }
 
 // OpenMongo connects to MongoDB using a connection string (DSN/URI).
@@ -41,6 +73,10 @@ func (c *RKT) OpenMongoDB(uri string) (*mongo.Client, error) {
 	defer cancel()
 
 	clientOpts := options.Client().ApplyURI(uri)
+	c.config.Database.Pool.applyMongo(clientOpts)
+
+	c.mongoPool = &mongoPoolMonitor{}
+	clientOpts.SetPoolMonitor(c.mongoPool.monitor())
 
 	client, err := mongo.Connect(ctx, clientOpts)
 	if err != nil {
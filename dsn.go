@@ -0,0 +1,233 @@
+package rkt
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// redacted replaces passwords in redacted connection strings
+const redacted = "xxxxx"
+
+// connString assembles a connection string from Host, Port, User, Password,
+// Name and SSLMode, for when no DSN is given. It returns "" when neither a
+// host nor a database name is set.
+func (d DatabaseConfig) connString() string {
+	if d.Type == "sqlite" {
+		return d.Name
+	}
+	if d.Host == "" && d.Name == "" {
+		return ""
+	}
+
+	host := d.Host
+	if host == "" {
+		host = "localhost"
+	}
+
+	switch d.Type {
+	case "postgres", "postgresql", "pgx":
+		u := url.URL{
+			Scheme: "postgres",
+			Host:   hostPort(host, d.Port, "5432"),
+			Path:   "/" + d.Name,
+		}
+		if d.User != "" {
+			u.User = url.UserPassword(d.User, d.Password)
+		}
+		if d.SSLMode != "" {
+			u.RawQuery = url.Values{"sslmode": {d.SSLMode}}.Encode()
+		}
+		return u.String()
+
+	case "mysql", "mariadb":
+		// the driver finds the credentials by the last @ before the database
+		// name, so they need no escaping; FormatDSN escapes the name itself
+		cfg := mysql.NewConfig()
+		cfg.User = d.User
+		cfg.Passwd = d.Password
+		cfg.Net = "tcp"
+		cfg.Addr = hostPort(host, d.Port, "3306")
+		cfg.DBName = d.Name
+		cfg.TLSConfig = mysqlTLS(d.SSLMode)
+		return cfg.FormatDSN()
+
+	case "mongodb", "mongo":
+		u := url.URL{
+			Scheme: "mongodb",
+			Host:   hostPort(host, d.Port, "27017"),
+			Path:   "/" + d.Name,
+		}
+		if d.User != "" {
+			u.User = url.UserPassword(d.User, d.Password)
+		}
+		if d.SSLMode != "" && d.SSLMode != "disable" {
+			u.RawQuery = "tls=true"
+		}
+		return u.String()
+	}
+
+	return ""
+}
+
+// hostPort joins host and port, using fallback when no port is set. Hosts
+// that already carry a port are left alone.
+func hostPort(host, port, fallback string) string {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+	if port == "" {
+		port = fallback
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// mysqlTLS maps a postgres style sslmode onto the mysql driver's tls
+// parameter. Values the driver understands itself are passed through.
+func mysqlTLS(sslMode string) string {
+	switch sslMode {
+	case "disable":
+		return "false"
+	case "allow", "prefer":
+		return "preferred"
+	case "require":
+		return "skip-verify"
+	case "verify-ca", "verify-full":
+		return "true"
+	}
+	return sslMode
+}
+
+// RedactedDSN returns BuildDSN with the password masked, for logs and errors
+func (d DatabaseConfig) RedactedDSN() string {
+	return RedactDSN(d.BuildDSN())
+}
+
+// RedactError masks the database password wherever it appears in err.
+// Drivers sometimes echo the connection string they failed to use.
+func (d DatabaseConfig) RedactError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	passwords := []string{d.Password}
+	if _, pass, ok := dsnPassword(d.BuildDSN()); ok {
+		passwords = append(passwords, pass)
+	}
+
+	msg := err.Error()
+	for _, pass := range passwords {
+		if pass == "" {
+			continue
+		}
+		msg = strings.ReplaceAll(msg, pass, redacted)
+		msg = strings.ReplaceAll(msg, url.QueryEscape(pass), redacted)
+	}
+	if msg == err.Error() {
+		return err
+	}
+	return errors.New(msg)
+}
+
+var keywordPassword = regexp.MustCompile(`(?i)(password\s*=\s*)('[^']*'|\S+)`)
+
+// RedactDSN masks the password in a connection string. It understands urls,
+// the mysql driver's user:pass@tcp(host)/db form and postgres keyword/value
+// strings.
+func RedactDSN(dsn string) string {
+	dsn = keywordPassword.ReplaceAllString(dsn, "${1}"+redacted)
+
+	at, pass, ok := dsnPassword(dsn)
+	if !ok || pass == "" {
+		return dsn
+	}
+	return dsn[:at-len(pass)-1] + ":" + redacted + dsn[at:]
+}
+
+// dsnPassword finds the password in the user:pass@ part of dsn, returning
+// the index of the @ that ends it
+func dsnPassword(dsn string) (int, string, bool) {
+	start := 0
+	if i := strings.Index(dsn, "://"); i >= 0 {
+		start = i + len("://")
+	}
+
+	// the password may hold an @, a / or a ?, so the credentials end at the
+	// last @ before the query
+	rest := dsn
+	if i := dsnQueryIndex(dsn); i >= 0 {
+		rest = dsn[:i]
+	}
+	rest = rest[start:]
+	at := strings.LastIndex(rest, "@")
+	if at < 0 {
+		return 0, "", false
+	}
+
+	_, pass, found := strings.Cut(rest[:at], ":")
+	if !found {
+		return 0, "", false
+	}
+	return start + at, pass, true
+}
+
+// mysqlMigrationURL turns a mysql driver dsn into the url golang-migrate
+// expects. golang-migrate query-unescapes the user and password, so they
+// are escaped here, and it reads any tls value other than a bool or
+// skip-verify as a custom profile, so the driver's preferred is dropped.
+func mysqlMigrationURL(dsn string) string {
+	end := len(dsn)
+	if i := dsnQueryIndex(dsn); i >= 0 {
+		end = i
+	}
+	if at := strings.LastIndex(dsn[:end], "@"); at >= 0 {
+		user, pass, hasPass := strings.Cut(dsn[:at], ":")
+		creds := url.QueryEscape(user)
+		if hasPass {
+			creds += ":" + url.QueryEscape(pass)
+		}
+		dsn = creds + dsn[at:]
+	}
+
+	dsn = withoutDSNParams(dsn, func(key, value string) bool {
+		return key == "tls" && strings.EqualFold(value, "preferred")
+	})
+	return "mysql://" + withDSNParam(dsn, "multiStatements", "true")
+}
+
+// withoutDSNParams drops the query parameters of dsn that drop matches
+func withoutDSNParams(dsn string, drop func(key, value string) bool) string {
+	i := dsnQueryIndex(dsn)
+	if i < 0 {
+		return dsn
+	}
+
+	var params []string
+	for _, param := range strings.Split(dsn[i+1:], "&") {
+		if key, value, _ := strings.Cut(param, "="); !drop(key, value) {
+			params = append(params, param)
+		}
+	}
+	if len(params) == 0 {
+		return dsn[:i]
+	}
+	return dsn[:i] + "?" + strings.Join(params, "&")
+}
+
+// dsnQueryIndex returns the index of the ? that starts the query of dsn, or
+// -1. Like the mysql driver, it looks after the last /, since an unescaped
+// mysql password may itself hold a ?.
+func dsnQueryIndex(dsn string) int {
+	slash := strings.LastIndex(dsn, "/")
+	if slash < 0 {
+		return strings.Index(dsn, "?")
+	}
+	if i := strings.Index(dsn[slash:], "?"); i >= 0 {
+		return slash + i
+	}
+	return -1
+}
@@ -0,0 +1,261 @@
+package rkt
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+// MigrationFunc is one direction of a migration written in Go. It runs in a
+// transaction that is committed when it returns nil.
+type MigrationFunc func(ctx context.Context, tx *sql.Tx) error
+
+type goMigration struct {
+	name     string
+	up, down MigrationFunc
+}
+
+/*
+Migrations is a set of migrations written in Go, for changes such as data
+backfills that are awkward in sql. An app only runs the Go migrations
+handed to it with UseMigrations or added with RegisterMigration, so that
+several apps in one process keep theirs apart.
+
+Migrations made with rkt make migration <name> --go register themselves in
+the Set of the app's migrations package, and the app's migrate command
+hands that set to the app.
+*/
+type Migrations struct {
+	migrations map[uint]goMigration
+}
+
+// Register adds a migration to the set. Its version interleaves with the
+// versions of the files in the migrations folder, and it is recorded in the
+// same version table, so MigrateUp, Steps and the other migration methods
+// run both kinds in order. down may be nil when the migration can't be
+// reversed.
+func (ms *Migrations) Register(version uint, name string, up, down MigrationFunc) {
+	if ms.migrations == nil {
+		ms.migrations = make(map[uint]goMigration)
+	}
+	if _, ok := ms.migrations[version]; ok {
+		panic(fmt.Sprintf("rkt: migration %d is registered twice", version))
+	}
+	ms.migrations[version] = goMigration{name: name, up: up, down: down}
+}
+
+// RegisterMigration adds a migration written in Go to this app, as
+// Migrations.Register does
+func (c *RKT) RegisterMigration(version uint, name string, up, down MigrationFunc) {
+	c.goMigrations.Register(version, name, up, down)
+}
+
+// UseMigrations adds the Go migrations in ms to this app. Call it before
+// New when MIGRATE_ON_START is set.
+func (c *RKT) UseMigrations(ms *Migrations) {
+	for version, m := range ms.migrations {
+		c.goMigrations.Register(version, m.name, m.up, m.down)
+	}
+}
+
+// goMigrationMarker starts the body that goSource hands to migrate for a Go
+// migration; goDriver recognises it and runs the function instead
+const goMigrationMarker = "-- rkt:go-migration "
+
+// goSource merges the registered Go migrations into a migration source
+type goSource struct {
+	source.Driver
+	migrations map[uint]goMigration
+	versions   []uint
+}
+
+// newGoSource wraps src, failing when a Go migration has the version of a
+// file in src
+func newGoSource(src source.Driver, migrations map[uint]goMigration) (*goSource, error) {
+	s := &goSource{Driver: src, migrations: migrations}
+
+	version, err := src.First()
+	for err == nil {
+		if m, ok := migrations[version]; ok {
+			return nil, fmt.Errorf("rkt: go migration %d_%s has the version of a migration file", version, m.name)
+		}
+		s.versions = append(s.versions, version)
+		version, err = src.Next(version)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	for version := range migrations {
+		s.versions = append(s.versions, version)
+	}
+	sort.Slice(s.versions, func(i, j int) bool { return s.versions[i] < s.versions[j] })
+
+	return s, nil
+}
+
+func (s *goSource) First() (uint, error) {
+	if len(s.versions) == 0 {
+		return 0, &fs.PathError{Op: "first", Path: "migrations", Err: fs.ErrNotExist}
+	}
+	return s.versions[0], nil
+}
+
+func (s *goSource) Prev(version uint) (uint, error) {
+	i := s.index(version)
+	if i <= 0 {
+		return 0, &fs.PathError{Op: "prev for version " + strconv.FormatUint(uint64(version), 10), Path: "migrations", Err: fs.ErrNotExist}
+	}
+	return s.versions[i-1], nil
+}
+
+func (s *goSource) Next(version uint) (uint, error) {
+	i := s.index(version)
+	if i < 0 || i == len(s.versions)-1 {
+		return 0, &fs.PathError{Op: "next for version " + strconv.FormatUint(uint64(version), 10), Path: "migrations", Err: fs.ErrNotExist}
+	}
+	return s.versions[i+1], nil
+}
+
+// index returns the position of version in versions, or -1
+func (s *goSource) index(version uint) int {
+	i := sort.Search(len(s.versions), func(i int) bool { return s.versions[i] >= version })
+	if i == len(s.versions) || s.versions[i] != version {
+		return -1
+	}
+	return i
+}
+
+func (s *goSource) ReadUp(version uint) (io.ReadCloser, string, error) {
+	if m, ok := s.migrations[version]; ok {
+		return goMigrationBody(version, "up"), m.name, nil
+	}
+	return s.Driver.ReadUp(version)
+}
+
+func (s *goSource) ReadDown(version uint) (io.ReadCloser, string, error) {
+	if m, ok := s.migrations[version]; ok {
+		if m.down == nil {
+			return nil, "", &fs.PathError{Op: "read down for version " + strconv.FormatUint(uint64(version), 10), Path: "migrations", Err: fs.ErrNotExist}
+		}
+		return goMigrationBody(version, "down"), m.name, nil
+	}
+	return s.Driver.ReadDown(version)
+}
+
+func goMigrationBody(version uint, direction string) io.ReadCloser {
+	body := fmt.Sprintf("%s%d %s\n", goMigrationMarker, version, direction)
+	return io.NopCloser(strings.NewReader(body))
+}
+
+// goDriver runs Go migrations against the database and passes every other
+// migration on to the golang-migrate driver it wraps. Go migrations get a
+// pool of their own, opened the first time one runs.
+type goDriver struct {
+	database.Driver
+	dsn        string
+	migrations map[uint]goMigration
+	db         *sql.DB
+}
+
+func (d *goDriver) Run(migration io.Reader) error {
+	body, err := io.ReadAll(migration)
+	if err != nil {
+		return err
+	}
+
+	if !bytes.HasPrefix(body, []byte(goMigrationMarker)) {
+		return d.Driver.Run(bytes.NewReader(body))
+	}
+
+	var version uint
+	var direction string
+	if _, err := fmt.Sscanf(string(body[len(goMigrationMarker):]), "%d %s", &version, &direction); err != nil {
+		return fmt.Errorf("rkt: malformed go migration: %w", err)
+	}
+
+	m := d.migrations[version]
+	fn := m.up
+	if direction == "down" {
+		fn = m.down
+	}
+
+	if d.db == nil {
+		driverName, dsn, err := sqlFromMigrationDSN(d.dsn)
+		if err != nil {
+			return err
+		}
+		d.db, err = sql.Open(driverName, dsn)
+		if err != nil {
+			return err
+		}
+	}
+
+	ctx := context.Background()
+	err = Database{Pool: d.db}.WithTx(ctx, nil, func(tx *sql.Tx) error {
+		return fn(ctx, tx)
+	})
+	if err != nil {
+		return fmt.Errorf("go migration %d_%s %s: %w", version, m.name, direction, err)
+	}
+	return nil
+}
+
+func (d *goDriver) Close() error {
+	err := d.Driver.Close()
+	if d.db != nil {
+		err = errors.Join(err, d.db.Close())
+	}
+	return err
+}
+
+// sqlFromMigrationDSN turns a golang-migrate database url into a
+// database/sql driver name and datasource name, without the x- parameters
+// that only golang-migrate understands
+func sqlFromMigrationDSN(dsn string) (string, string, error) {
+	scheme, rest, ok := strings.Cut(dsn, "://")
+	if !ok {
+		return "", "", fmt.Errorf("rkt: go migrations can't use the database url %s", RedactDSN(dsn))
+	}
+
+	switch scheme {
+	case "postgres", "postgresql":
+		return "pgx", withoutMigrateParams(dsn), nil
+	case "mysql":
+		// the user and password are query-escaped in the url, as for
+		// golang-migrate itself
+		cfg, err := mysql.ParseDSN(withoutMigrateParams(rest))
+		if err != nil {
+			return "", "", err
+		}
+		if cfg.User, err = url.QueryUnescape(cfg.User); err != nil {
+			return "", "", err
+		}
+		if cfg.Passwd, err = url.QueryUnescape(cfg.Passwd); err != nil {
+			return "", "", err
+		}
+		return "mysql", cfg.FormatDSN(), nil
+	case "sqlite":
+		return "sqlite", withoutMigrateParams(rest), nil
+	}
+	return "", "", fmt.Errorf("rkt: go migrations need a sql database, not %s", scheme)
+}
+
+// withoutMigrateParams drops the x- query parameters from dsn
+func withoutMigrateParams(dsn string) string {
+	return withoutDSNParams(dsn, func(key, _ string) bool {
+		return strings.HasPrefix(key, "x-")
+	})
+}
@@ -0,0 +1,149 @@
+package rkt
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dgraph-io/badger/v3"
+)
+
+// HealthCheck probes a single dependency. A nil error means it is healthy.
+type HealthCheck func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check HealthCheck
+}
+
+type healthReport struct {
+	Status string                 `json:"status"`
+	Checks map[string]checkResult `json:"checks,omitempty"`
+}
+
+type checkResult struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// AddHealthCheck registers a check that the readiness endpoint runs
+// alongside the built in database and cache checks
+func (r *RKT) AddHealthCheck(name string, check HealthCheck) {
+	r.healthChecks = append(r.healthChecks, namedCheck{name: name, check: check})
+}
+
+// Healthz reports that the process is up and serving requests
+func (r *RKT) Healthz(w http.ResponseWriter, req *http.Request) {
+	_ = r.WriteJSON(w, http.StatusOK, healthReport{Status: "ok"})
+}
+
+// Readyz probes every configured backend and every registered check in
+// parallel, and answers 503 if any of them fails or the app is shutting down
+func (r *RKT) Readyz(w http.ResponseWriter, req *http.Request) {
+	if r.shuttingDown.Load() {
+		_ = r.WriteJSON(w, http.StatusServiceUnavailable, healthReport{Status: "shutting down"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), r.config.Health.Timeout)
+	defer cancel()
+
+	checks := append(r.backendChecks(), r.healthChecks...)
+	report := healthReport{
+		Status: "ok",
+		Checks: make(map[string]checkResult, len(checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, c := range checks {
+		wg.Add(1)
+		go func(c namedCheck) {
+			defer wg.Done()
+
+			start := time.Now()
+			err := runCheck(ctx, c.check)
+			result := checkResult{Status: "ok", Duration: time.Since(start).String()}
+			if err != nil {
+				result.Status = "error"
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[c.name] = result
+			if err != nil {
+				report.Status = "unavailable"
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	status := http.StatusOK
+	if report.Status != "ok" {
+		status = http.StatusServiceUnavailable
+	}
+	_ = r.WriteJSON(w, status, report)
+}
+
+// runCheck runs check but gives up when ctx expires, even if the check
+// itself ignores the context
+func runCheck(ctx context.Context, check HealthCheck) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- check(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return errors.New("timed out")
+	}
+}
+
+// backendChecks returns a check for each backend opened by New
+func (r *RKT) backendChecks() []namedCheck {
+	var checks []namedCheck
+
+	if r.DB.Pool != nil {
+		checks = append(checks, namedCheck{name: "database", check: func(ctx context.Context) error {
+			return r.DB.Pool.PingContext(ctx)
+		}})
+	}
+
+	if r.DB.Conn != nil {
+		checks = append(checks, namedCheck{name: "database", check: func(ctx context.Context) error {
+			return r.DB.Conn.Ping(ctx, nil)
+		}})
+	}
+
+	if r.redisPool != nil {
+		checks = append(checks, namedCheck{name: "redis", check: func(ctx context.Context) error {
+			conn, err := r.redisPool.GetContext(ctx)
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			_, err = conn.Do("PING")
+			return err
+		}})
+	}
+
+	if r.badgerConn != nil {
+		checks = append(checks, namedCheck{name: "badger", check: func(ctx context.Context) error {
+			if r.badgerConn.IsClosed() {
+				return errors.New("database is closed")
+			}
+			return r.badgerConn.View(func(txn *badger.Txn) error {
+				return nil
+			})
+		}})
+	}
+
+	return checks
+}
@@ -0,0 +1,126 @@
+package rkt
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// withProbedBackends gives the readiness probe a database and a cache to check
+func withProbedBackends(cfg *Config) {
+	withSQLite(cfg)
+	cfg.Cache.Type = "badger"
+}
+
+// probe sends a GET for path through the app's handler
+func probe(t *testing.T, app *RKT, path string) (int, healthReport) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	app.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+	var report healthReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("%s: %v in %q", path, err, rec.Body.String())
+	}
+	return rec.Code, report
+}
+
+func TestRKT_HealthEndpoints(t *testing.T) {
+	app := newTestApp(t, withProbedBackends)
+
+	if code, report := probe(t, app, "/healthz"); code != http.StatusOK || report.Status != "ok" {
+		t.Errorf("healthz: expected 200 ok, got %d %+v", code, report)
+	}
+
+	code, report := probe(t, app, "/readyz")
+	if code != http.StatusOK || report.Status != "ok" {
+		t.Fatalf("readyz: expected 200 ok, got %d %+v", code, report)
+	}
+	for _, name := range []string{"database", "badger"} {
+		if report.Checks[name].Status != "ok" {
+			t.Errorf("readyz: expected the %s check to pass, got %+v", name, report.Checks[name])
+		}
+	}
+}
+
+func TestRKT_ReadyzFailingChecks(t *testing.T) {
+	app := newTestApp(t, withProbedBackends)
+
+	app.AddHealthCheck("queue", func(ctx context.Context) error {
+		return errors.New("queue is down")
+	})
+
+	code, report := probe(t, app, "/readyz")
+	if code != http.StatusServiceUnavailable || report.Status != "unavailable" {
+		t.Errorf("expected 503 unavailable for a failing check, got %d %+v", code, report)
+	}
+	if got := report.Checks["queue"]; got.Status != "error" || got.Error != "queue is down" {
+		t.Errorf("expected the queue check to report its error, got %+v", got)
+	}
+	if report.Checks["database"].Status != "ok" {
+		t.Errorf("expected the database check to pass, got %+v", report.Checks["database"])
+	}
+
+	// a backend that stops answering fails the probe too
+	if err := app.DB.Pool.Close(); err != nil {
+		t.Fatal(err)
+	}
+	code, report = probe(t, app, "/readyz")
+	if code != http.StatusServiceUnavailable || report.Checks["database"].Status != "error" {
+		t.Errorf("expected 503 with a failing database check, got %d %+v", code, report)
+	}
+
+	// liveness doesn't depend on the backends
+	if code, _ := probe(t, app, "/healthz"); code != http.StatusOK {
+		t.Errorf("healthz: expected 200, got %d", code)
+	}
+}
+
+func TestRKT_ReadyzShuttingDown(t *testing.T) {
+	app := newTestApp(t, withProbedBackends)
+
+	app.shuttingDown.Store(true)
+
+	code, report := probe(t, app, "/readyz")
+	if code != http.StatusServiceUnavailable || report.Status != "shutting down" {
+		t.Errorf("expected 503 shutting down, got %d %+v", code, report)
+	}
+}
+
+func TestRKT_HealthEndpointsSkipTheApp(t *testing.T) {
+	app := newTestApp(t, withProbedBackends)
+
+	// apps can still add middleware once the probes are set up
+	called := false
+	app.Routes.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+			next.ServeHTTP(w, r)
+		})
+	})
+	app.Routes.Get("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, method := range []string{http.MethodGet, http.MethodHead} {
+		rec := httptest.NewRecorder()
+		app.Handler().ServeHTTP(rec, httptest.NewRequest(method, "/healthz", nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s healthz: expected 200, got %d", method, rec.Code)
+		}
+		if cookies := rec.Result().Cookies(); len(cookies) != 0 {
+			t.Errorf("%s healthz: expected no session or csrf cookies, got %v", method, cookies)
+		}
+	}
+	if called {
+		t.Error("expected the probes to skip the app's middleware")
+	}
+
+	rec := httptest.NewRecorder()
+	app.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK || !called {
+		t.Errorf("expected other paths to reach the app's routes, got %d", rec.Code)
+	}
+}
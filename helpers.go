@@ -7,6 +7,7 @@ import (
 	"encoding/base64"
 	"io"
 	"os"
+	"path/filepath"
 )
 
 const (
@@ -51,6 +52,14 @@ func (r *RKT) CreateFileIfNotExists(path string) error {
 	return nil
 }
 
+// absPath resolves a path from the environment against the project root
+func (r *RKT) absPath(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(r.RootPath, path)
+}
+
 type Encryption struct {
 	Key []byte
 }
@@ -0,0 +1,122 @@
+package rkt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Hook is a lifecycle callback registered with OnBoot, OnStart or OnShutdown
+type Hook func(ctx context.Context) error
+
+// Service is a long running component, such as a job worker or websocket
+// hub, that is started and stopped together with the app
+type Service interface {
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
+
+type namedService struct {
+	name    string
+	service Service
+}
+
+// OnBoot registers a hook that runs at the end of New, once every backend
+// is connected. Hooks run in the order they were registered, and must be
+// registered before New is called.
+func (r *RKT) OnBoot(fn Hook) {
+	r.bootHooks = append(r.bootHooks, fn)
+}
+
+// OnStart registers a hook that runs in Start, before the registered services
+// and the scheduler are started. Hooks run in the order they were registered.
+func (r *RKT) OnStart(fn Hook) {
+	r.startHooks = append(r.startHooks, fn)
+}
+
+// OnShutdown registers a hook that runs during Shutdown, after the http
+// server has drained and the services have stopped, and before the database
+// and cache pools are closed. Hooks run in reverse order of registration.
+func (r *RKT) OnShutdown(fn Hook) {
+	r.shutdownHooks = append(r.shutdownHooks, fn)
+}
+
+// Register adds a named service to the app. Services are started in the
+// order they were registered and stopped in reverse order.
+func (r *RKT) Register(name string, svc Service) error {
+	r.servicesMu.Lock()
+	defer r.servicesMu.Unlock()
+
+	for _, s := range r.services {
+		if s.name == name {
+			return fmt.Errorf("rkt: service %q is already registered", name)
+		}
+	}
+
+	r.services = append(r.services, namedService{name: name, service: svc})
+	return nil
+}
+
+// Service looks up a registered service by name
+func (r *RKT) Service(name string) (Service, bool) {
+	r.servicesMu.RLock()
+	defer r.servicesMu.RUnlock()
+
+	for _, s := range r.services {
+		if s.name == name {
+			return s.service, true
+		}
+	}
+	return nil, false
+}
+
+// runBootHooks is called at the end of New
+func (r *RKT) runBootHooks(ctx context.Context) error {
+	for _, fn := range r.bootHooks {
+		if err := fn(ctx); err != nil {
+			return fmt.Errorf("rkt: boot hook: %w", err)
+		}
+	}
+	return nil
+}
+
+// Start runs the OnStart hooks, starts the registered services and then the
+// scheduler. If a service fails to start, the services already started are
+// stopped again. ListenAndServe calls Start before it begins serving.
+func (r *RKT) Start(ctx context.Context) error {
+	for _, fn := range r.startHooks {
+		if err := fn(ctx); err != nil {
+			return fmt.Errorf("rkt: start hook: %w", err)
+		}
+	}
+
+	r.servicesMu.RLock()
+	services := append([]namedService(nil), r.services...)
+	r.servicesMu.RUnlock()
+
+	for i, s := range services {
+		if err := s.service.Start(ctx); err != nil {
+			err = fmt.Errorf("rkt: starting service %s: %w", s.name, err)
+			return errors.Join(err, stopServices(ctx, services[:i]))
+		}
+		r.Logger.Info("Started service", "service", s.name)
+	}
+	r.started = services
+
+	if r.Scheduler != nil {
+		r.Scheduler.Start()
+	}
+
+	return nil
+}
+
+// stopServices stops services in reverse order and joins their errors
+func stopServices(ctx context.Context, services []namedService) error {
+	var errs []error
+	for i := len(services) - 1; i >= 0; i-- {
+		if err := services[i].service.Stop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("rkt: stopping service %s: %w", services[i].name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,105 @@
+package rkt
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type recordingService struct {
+	name     string
+	events   *[]string
+	startErr error
+}
+
+func (s *recordingService) Start(ctx context.Context) error {
+	*s.events = append(*s.events, "start "+s.name)
+	return s.startErr
+}
+
+func (s *recordingService) Stop(ctx context.Context) error {
+	*s.events = append(*s.events, "stop "+s.name)
+	return nil
+}
+
+func TestRKT_Lifecycle(t *testing.T) {
+	var events []string
+	var r RKT
+
+	r.OnBoot(func(ctx context.Context) error {
+		events = append(events, "boot")
+		return nil
+	})
+	r.OnStart(func(ctx context.Context) error {
+		events = append(events, "start hook")
+		return nil
+	})
+	r.OnShutdown(func(ctx context.Context) error {
+		events = append(events, "shutdown hook 1")
+		return nil
+	})
+	r.OnShutdown(func(ctx context.Context) error {
+		events = append(events, "shutdown hook 2")
+		return nil
+	})
+
+	if err := r.NewWithConfig(t.TempDir(), testConfig(nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Register("worker", &recordingService{name: "worker", events: &events}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register("hub", &recordingService{name: "hub", events: &events}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register("hub", &recordingService{name: "hub", events: &events}); err == nil {
+		t.Error("expected registering a duplicate name to fail")
+	}
+	if _, ok := r.Service("hub"); !ok {
+		t.Error("expected to find the hub service")
+	}
+
+	if err := r.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"boot",
+		"start hook",
+		"start worker",
+		"start hub",
+		"stop hub",
+		"stop worker",
+		"shutdown hook 2",
+		"shutdown hook 1",
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("unexpected lifecycle order\n got: %v\nwant: %v", events, expected)
+	}
+}
+
+func TestRKT_StartRollsBackServices(t *testing.T) {
+	var events []string
+	var r RKT
+
+	if err := r.NewWithConfig(t.TempDir(), testConfig(nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	_ = r.Register("first", &recordingService{name: "first", events: &events})
+	_ = r.Register("broken", &recordingService{name: "broken", events: &events, startErr: errors.New("boom")})
+
+	if err := r.Start(context.Background()); err == nil {
+		t.Fatal("expected start to fail")
+	}
+
+	expected := []string{"start first", "start broken", "stop first"}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("unexpected order\n got: %v\nwant: %v", events, expected)
+	}
+}
@@ -0,0 +1,102 @@
+package rkt
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+type loggerKey struct{}
+
+/*
+startLoggers builds the slog based Logger from the log config. Output goes
+to stdout, and also to a size rotated file under logs/ when LOG_FILE is true.
+InfoLog and ErrorLog are kept as adapters that write through Logger at the
+info and error levels, so existing callers keep working.
+*/
+func (r *RKT) startLoggers() {
+	var out io.Writer = os.Stdout
+
+	if r.config.Log.File {
+		file := &lumberjack.Logger{
+			Filename:   filepath.Join(r.RootPath, "logs", r.logFileName()),
+			MaxSize:    r.config.Log.MaxSize,
+			MaxBackups: r.config.Log.MaxBackups,
+			MaxAge:     r.config.Log.MaxAge,
+		}
+		r.logFile = file
+		out = io.MultiWriter(os.Stdout, file)
+	}
+
+	handler := r.logHandler(out)
+
+	r.Logger = slog.New(handler)
+	r.InfoLog = slog.NewLogLogger(handler, slog.LevelInfo)
+	r.ErrorLog = slog.NewLogLogger(handler, slog.LevelError)
+}
+
+// logHandler returns the text or json handler LOG_FORMAT asks for, writing
+// to out at the LOG_LEVEL level
+func (r *RKT) logHandler(out io.Writer) slog.Handler {
+	opts := &slog.HandlerOptions{Level: r.logLevel()}
+
+	if strings.ToLower(r.config.Log.Format) == "json" {
+		return slog.NewJSONHandler(out, opts)
+	}
+	return slog.NewTextHandler(out, opts)
+}
+
+// logLevel parses LOG_LEVEL, defaulting to debug in debug mode and info otherwise
+func (r *RKT) logLevel() slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(r.config.Log.Level)); err != nil {
+		if r.config.Debug {
+			return slog.LevelDebug
+		}
+		return slog.LevelInfo
+	}
+	return level
+}
+
+func (r *RKT) logFileName() string {
+	if r.config.AppName != "" {
+		return r.config.AppName + ".log"
+	}
+	return "rkt.log"
+}
+
+// LogRequest is middleware that stores a request scoped logger in the request
+// context, carrying the request id, method, path and, for a logged in user,
+// the user id. It must run after RequestID and SessionLoad.
+func (r *RKT) LogRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		logger := r.Logger.With(
+			slog.String("request_id", middleware.GetReqID(req.Context())),
+			slog.String("method", req.Method),
+			slog.String("path", req.URL.Path),
+		)
+
+		if r.Session != nil && r.Session.Exists(req.Context(), "userID") {
+			logger = logger.With(slog.Any("user_id", r.Session.Get(req.Context(), "userID")))
+		}
+
+		ctx := context.WithValue(req.Context(), loggerKey{}, logger)
+		next.ServeHTTP(w, req.WithContext(ctx))
+	})
+}
+
+// Log returns the request scoped logger set by LogRequest, or Logger when
+// the request didn't pass through it
+func (r *RKT) Log(req *http.Request) *slog.Logger {
+	if logger, ok := req.Context().Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return r.Logger
+}
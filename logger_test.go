@@ -0,0 +1,152 @@
+package rkt
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+func TestRKT_LogHandlerFormat(t *testing.T) {
+	var buf bytes.Buffer
+
+	app := &RKT{}
+	app.config.Log.Format = "JSON"
+	slog.New(app.logHandler(&buf)).Info("hello", "n", 1)
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("expected a json line, got %q: %v", buf.String(), err)
+	}
+	if entry["msg"] != "hello" || entry["level"] != "INFO" || entry["n"] != float64(1) {
+		t.Errorf("unexpected entry %v", entry)
+	}
+
+	buf.Reset()
+	app.config.Log.Format = "text"
+	slog.New(app.logHandler(&buf)).Info("hello", "n", 1)
+	if got := buf.String(); !strings.Contains(got, "level=INFO msg=hello n=1") {
+		t.Errorf("expected a text line, got %q", got)
+	}
+}
+
+func TestRKT_LogLevel(t *testing.T) {
+	tests := []struct {
+		level   string
+		debug   bool
+		logged  []string
+		dropped []string
+	}{
+		{level: "warn", logged: []string{"warn", "error"}, dropped: []string{"debug", "info"}},
+		{level: "DEBUG", logged: []string{"debug", "info"}},
+		{level: "", logged: []string{"info"}, dropped: []string{"debug"}},
+		{level: "", debug: true, logged: []string{"debug"}},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+
+		app := &RKT{}
+		app.config.Log.Level = tt.level
+		app.config.Debug = tt.debug
+		logger := slog.New(app.logHandler(&buf))
+
+		logger.Debug("debug")
+		logger.Info("info")
+		logger.Warn("warn")
+		logger.Error("error")
+
+		for _, msg := range tt.logged {
+			if !strings.Contains(buf.String(), "msg="+msg) {
+				t.Errorf("level %q, debug %v: expected %s to be logged", tt.level, tt.debug, msg)
+			}
+		}
+		for _, msg := range tt.dropped {
+			if strings.Contains(buf.String(), "msg="+msg) {
+				t.Errorf("level %q, debug %v: expected %s to be dropped", tt.level, tt.debug, msg)
+			}
+		}
+	}
+}
+
+func TestRKT_LogFile(t *testing.T) {
+	app := &RKT{RootPath: t.TempDir()}
+	app.config.AppName = "myapp"
+	app.config.Log.File = true
+	app.config.Log.Level = "error"
+	app.startLoggers()
+
+	app.Logger.Error("written to the file")
+	app.ErrorLog.Println("through the log adapter")
+	if err := app.logFile.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(app.RootPath, "logs", "myapp.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range []string{"written to the file", "through the log adapter"} {
+		if !bytes.Contains(data, []byte(msg)) {
+			t.Errorf("expected %q in the log file, got %q", msg, data)
+		}
+	}
+}
+
+func TestRKT_LogRequest(t *testing.T) {
+	var buf bytes.Buffer
+
+	app := &RKT{Session: scs.New()}
+	app.config.Log.Format = "json"
+	app.Logger = slog.New(app.logHandler(&buf))
+
+	handler := middleware.RequestID(app.Session.LoadAndSave(app.LogRequest(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/login" {
+			app.Session.Put(req.Context(), "userID", 7)
+			return
+		}
+		app.Log(req).Info("handled")
+	}))))
+
+	// logs the entry of a request to path and decodes it
+	logged := func(path string, cookies []*http.Cookie) map[string]any {
+		t.Helper()
+		buf.Reset()
+
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		var entry map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+			t.Fatalf("%s: expected a json line, got %q: %v", path, buf.String(), err)
+		}
+		return entry
+	}
+
+	entry := logged("/orders?page=2", nil)
+	if entry["method"] != "GET" || entry["path"] != "/orders" || entry["request_id"] == "" || entry["request_id"] == nil {
+		t.Errorf("expected the request fields, got %v", entry)
+	}
+	if _, ok := entry["user_id"]; ok {
+		t.Errorf("expected no user id for a guest, got %v", entry)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/login", nil))
+
+	entry = logged("/orders", rec.Result().Cookies())
+	if entry["user_id"] != float64(7) {
+		t.Errorf("expected the logged in user's id, got %v", entry)
+	}
+}
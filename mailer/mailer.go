@@ -21,6 +21,15 @@ type Mail struct {
 	FromName    string
 	PublicAPI   string
 	PrivateAPI  string
+	// OnSend, when set, is called after every Send with its result
+	OnSend func(msg Message, err error)
+	// Transport, when set, delivers messages instead of the mailjet API
+	Transport Transport
+}
+
+// Transport delivers a message once its html and plain text bodies are rendered
+type Transport interface {
+	Deliver(msg Message, html, plain string) error
 }
 
 // Message is the type for an email message
@@ -36,7 +45,14 @@ type Message struct {
 }
 
 func (m *Mail) Send(msg Message) error {
+	err := m.send(msg)
+	if m.OnSend != nil {
+		m.OnSend(msg, err)
+	}
+	return err
+}
 
+func (m *Mail) send(msg Message) error {
 	formattedMessage, err := m.buildHTMLMessage(msg)
 	if err != nil {
 		return err
@@ -47,6 +63,10 @@ func (m *Mail) Send(msg Message) error {
 		return err
 	}
 
+	if m.Transport != nil {
+		return m.Transport.Deliver(msg, formattedMessage, plainMessage)
+	}
+
 	mailjetClient := mailjet.NewMailjetClient(m.PublicAPI, m.PrivateAPI)
 	messagesInfo := []mailjet.InfoMessagesV31{
 		{
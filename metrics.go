@@ -0,0 +1,123 @@
+package rkt
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/m-goku/rkt/cache"
+	"github.com/m-goku/rkt/mailer"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metrics holds the prometheus registry of one RKT instance. Each instance
+// gets its own registry so that several apps can live in one process.
+type metrics struct {
+	registry *prometheus.Registry
+	handler  http.Handler
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	mail     *prometheus.CounterVec
+}
+
+// startMetrics registers collectors for http, the database pool, redis,
+// the cache and the mailer. It is only called when METRICS_ENABLED is true.
+func (r *RKT) startMetrics() {
+	reg := prometheus.NewRegistry()
+
+	m := &metrics{
+		registry: reg,
+		handler:  promhttp.HandlerFor(reg, promhttp.HandlerOpts{}),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "rkt_http_requests_total",
+			Help: "HTTP requests handled, by method, chi route pattern and status code.",
+		}, []string{"method", "route", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "rkt_http_request_duration_seconds",
+			Help:    "HTTP request latency, by method and chi route pattern.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		mail: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "rkt_mail_sent_total",
+			Help: "Mail messages sent, by result (success or failure).",
+		}, []string{"result"}),
+	}
+
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.duration,
+		m.mail,
+	)
+
+	if r.DB.Pool != nil {
+		reg.MustRegister(collectors.NewDBStatsCollector(r.DB.Pool, r.DB.DataType))
+	}
+
+	if r.redisPool != nil {
+		pool := r.redisPool
+		reg.MustRegister(
+			prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+				Name: "rkt_redis_pool_active_connections",
+				Help: "Connections in the redis pool, in use or idle.",
+			}, func() float64 { return float64(pool.Stats().ActiveCount) }),
+			prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+				Name: "rkt_redis_pool_idle_connections",
+				Help: "Idle connections in the redis pool.",
+			}, func() float64 { return float64(pool.Stats().IdleCount) }),
+		)
+	}
+
+	if stats, ok := r.Cache.(cache.StatsReporter); ok {
+		reg.MustRegister(
+			prometheus.NewCounterFunc(prometheus.CounterOpts{
+				Name: "rkt_cache_hits_total",
+				Help: "Cache Get calls that found the key.",
+			}, func() float64 { return float64(stats.Hits()) }),
+			prometheus.NewCounterFunc(prometheus.CounterOpts{
+				Name: "rkt_cache_misses_total",
+				Help: "Cache Get calls that did not find the key.",
+			}, func() float64 { return float64(stats.Misses()) }),
+		)
+	}
+
+	r.Mail.OnSend = func(msg mailer.Message, err error) {
+		if err != nil {
+			m.mail.WithLabelValues("failure").Inc()
+			return
+		}
+		m.mail.WithLabelValues("success").Inc()
+	}
+
+	r.metrics = m
+}
+
+// instrument is middleware that records the count and latency of the app's
+// requests by their chi route pattern
+func (r *RKT) instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, req.ProtoMajor)
+		next.ServeHTTP(ww, req)
+
+		// the pattern is only known once chi has routed the request; unmatched
+		// paths share one label so that scanners can't blow up cardinality
+		route := "unmatched"
+		if rctx := chi.RouteContext(req.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		r.metrics.requests.WithLabelValues(req.Method, route, strconv.Itoa(status)).Inc()
+		r.metrics.duration.WithLabelValues(req.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
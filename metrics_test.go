@@ -0,0 +1,48 @@
+package rkt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRKT_Metrics(t *testing.T) {
+	app := newTestApp(t, func(cfg *Config) { cfg.Metrics.Enabled = true })
+	path := app.config.Metrics.Path
+
+	app.Routes.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	rec := httptest.NewRecorder()
+	app.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/42", nil))
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", rec.Code)
+	}
+	app.Handler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nope", nil))
+
+	rec = httptest.NewRecorder()
+	app.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected the metrics handler to answer 200, got %d", rec.Code)
+	}
+
+	// requests are labelled by route pattern, not by path
+	body := rec.Body.String()
+	for _, series := range []string{
+		`rkt_http_requests_total{method="GET",route="/users/{id}",status="202"} 1`,
+		`rkt_http_request_duration_seconds_count{method="GET",route="/users/{id}"} 1`,
+		`rkt_http_request_duration_seconds_bucket{method="GET",route="/users/{id}",le="+Inf"} 1`,
+		`rkt_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+	} {
+		if !strings.Contains(body, series) {
+			t.Errorf("expected the series %s, got:\n%s", series, body)
+		}
+	}
+
+	// scraping the metrics is not itself counted
+	if strings.Contains(body, `route="`+path+`"`) {
+		t.Errorf("expected the metrics path to be left out of the request metrics")
+	}
+}
@@ -1,8 +1,8 @@
 package rkt
 
 import (
+	"fmt"
 	"net/http"
-	"strconv"
 
 	"github.com/justinas/nosurf"
 )
@@ -13,17 +13,25 @@ func (r *RKT) SessionLoad(next http.Handler) http.Handler {
 
 func (c *RKT) NoSurf(next http.Handler) http.Handler {
 	csrfHandler := nosurf.New(next)
-	secure, _ := strconv.ParseBool(c.config.cookie.secure)
-
 	csrfHandler.ExemptGlob("/api/*")
 
 	csrfHandler.SetBaseCookie(http.Cookie{
 		HttpOnly: true,
 		Path:     "/",
-		Secure:   secure,
+		Secure:   c.config.Cookie.Secure,
 		SameSite: http.SameSiteStrictMode,
-		Domain:   c.config.cookie.domain,
+		Domain:   c.config.Cookie.Domain,
 	})
 
 	return csrfHandler
 }
+
+// HSTS sets the Strict-Transport-Security header on responses served over TLS
+func (c *RKT) HSTS(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil && c.config.Server.HSTSMaxAge > 0 {
+			w.Header().Set("Strict-Transport-Security", fmt.Sprintf("max-age=%d; includeSubDomains", c.config.Server.HSTSMaxAge))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
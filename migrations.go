@@ -1,15 +1,26 @@
 package rkt
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
 
 	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	_ "github.com/golang-migrate/migrate/v4/database/mongodb"
+	_ "github.com/golang-migrate/migrate/v4/database/mysql"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 )
 
 func (c *RKT) getMigrationPath() (string, error) {
@@ -33,15 +44,103 @@ func (c *RKT) getMigrationPath() (string, error) {
 	return "file://" + migrationDir, nil
 }
 
-func (c *RKT) MigrateUp(dsn string) error {
+// UseMigrationsFS makes the migration methods read migrations from fsys
+// instead of the migrations folder on disk, so that they can be embedded in
+// the binary. fsys holds the files at its root, or in a migrations folder
+// at its root, which is what //go:embed migrations gives:
+//
+//	//go:embed migrations
+//	var migrationsFS embed.FS
+//
+//	app.UseMigrationsFS(migrationsFS)
+func (c *RKT) UseMigrationsFS(fsys fs.FS) error {
+	if info, err := fs.Stat(fsys, "migrations"); err == nil && info.IsDir() {
+		sub, err := fs.Sub(fsys, "migrations")
+		if err != nil {
+			return err
+		}
+		fsys = sub
+	}
+
+	c.migrationsFS = fsys
+	return nil
+}
+
+// openMigrationSource opens the embedded migrations when UseMigrationsFS
+// was called, and the migrations folder otherwise, merged with the Go
+// migrations of the app
+func (c *RKT) openMigrationSource() (string, source.Driver, error) {
+	name, src, err := c.openMigrationFiles()
+	if err != nil {
+		return "", nil, err
+	}
+
+	migrations := c.goMigrations.migrations
+	if len(migrations) == 0 {
+		return name, src, nil
+	}
+
+	merged, err := newGoSource(src, migrations)
+	if err != nil {
+		_ = src.Close()
+		return "", nil, err
+	}
+	return name, merged, nil
+}
+
+func (c *RKT) openMigrationFiles() (string, source.Driver, error) {
+	if c.migrationsFS != nil {
+		src, err := iofs.New(c.migrationsFS, ".")
+		return "iofs", src, err
+	}
+
 	migrationPath, err := c.getMigrationPath()
 	if err != nil {
-		return err
+		return "", nil, err
+	}
+	src, err := source.Open(migrationPath)
+	return "file", src, err
+}
+
+// newMigrate opens a migrate instance over the migration source
+func (c *RKT) newMigrate(dsn string) (*migrate.Migrate, error) {
+	name, src, err := c.openMigrationSource()
+	if err != nil {
+		return nil, err
+	}
+
+	gs, ok := src.(*goSource)
+	if !ok {
+		m, err := migrate.NewWithSourceInstance(name, src, dsn)
+		if err != nil {
+			_ = src.Close()
+			return nil, fmt.Errorf("failed to create migrate instance: %w", err)
+		}
+		return m, nil
+	}
+
+	// Go migrations are run by a driver wrapping the one golang-migrate
+	// would open for dsn
+	db, err := database.Open(dsn)
+	if err != nil {
+		_ = src.Close()
+		return nil, fmt.Errorf("failed to create migrate instance: %w", err)
 	}
+	scheme, _, _ := strings.Cut(dsn, "://")
 
-	m, err := migrate.New(migrationPath, dsn)
+	m, err := migrate.NewWithInstance(name, src, scheme, &goDriver{Driver: db, dsn: dsn, migrations: gs.migrations})
 	if err != nil {
-		return fmt.Errorf("failed to create migrate instance: %w", err)
+		_ = src.Close()
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to create migrate instance: %w", err)
+	}
+	return m, nil
+}
+
+func (c *RKT) MigrateUp(dsn string) error {
+	m, err := c.newMigrate(dsn)
+	if err != nil {
+		return err
 	}
 	defer m.Close()
 
@@ -53,15 +152,10 @@ func (c *RKT) MigrateUp(dsn string) error {
 }
 
 func (c *RKT) MigrateDownAll(dsn string) error {
-	migrationPath, err := c.getMigrationPath()
+	m, err := c.newMigrate(dsn)
 	if err != nil {
 		return err
 	}
-
-	m, err := migrate.New(migrationPath, dsn)
-	if err != nil {
-		return fmt.Errorf("failed to create migrate instance: %w", err)
-	}
 	defer m.Close()
 
 	if err := m.Down(); err != nil {
@@ -71,15 +165,10 @@ func (c *RKT) MigrateDownAll(dsn string) error {
 }
 
 func (c *RKT) Steps(n int, dsn string) error {
-	migrationPath, err := c.getMigrationPath()
+	m, err := c.newMigrate(dsn)
 	if err != nil {
 		return err
 	}
-
-	m, err := migrate.New(migrationPath, dsn)
-	if err != nil {
-		return fmt.Errorf("failed to create migrate instance: %w", err)
-	}
 	defer m.Close()
 
 	if err := m.Steps(n); err != nil {
@@ -88,20 +177,223 @@ func (c *RKT) Steps(n int, dsn string) error {
 	return nil
 }
 
+// MigrateForce clears the version and dirty flag, leaving the database
+// marked as having no migrations applied. Use MigrateForceVersion to mark a
+// specific version as applied instead.
 func (c *RKT) MigrateForce(dsn string) error {
-	migrationPath, err := c.getMigrationPath()
+	return c.MigrateForceVersion(-1, dsn)
+}
+
+// MigrateForceVersion marks version as the current, clean version without
+// running any migration. It is how a dirty database is repaired after the
+// failed migration has been fixed by hand; -1 means no version.
+func (c *RKT) MigrateForceVersion(version int, dsn string) error {
+	m, err := c.newMigrate(dsn)
 	if err != nil {
 		return err
 	}
+	defer m.Close()
 
-	m, err := migrate.New(migrationPath, dsn)
+	return m.Force(version)
+}
+
+// MigrateTo migrates up or down until version is the current version
+func (c *RKT) MigrateTo(version uint, dsn string) error {
+	m, err := c.newMigrate(dsn)
 	if err != nil {
-		return fmt.Errorf("failed to create migrate instance: %w", err)
+		return err
 	}
 	defer m.Close()
 
-	if err := m.Force(-1); err != nil {
+	if err := m.Migrate(version); err != nil && err != migrate.ErrNoChange {
 		return err
 	}
 	return nil
 }
+
+// MigrateDown reverses the n most recent migrations
+func (c *RKT) MigrateDown(n int, dsn string) error {
+	if n < 1 {
+		return fmt.Errorf("can't migrate down %d steps", n)
+	}
+	return c.Steps(-n, dsn)
+}
+
+// MigrateFresh drops everything in the database, migrations table included,
+// and then runs every up migration
+func (c *RKT) MigrateFresh(dsn string) error {
+	m, err := c.newMigrate(dsn)
+	if err != nil {
+		return err
+	}
+
+	err = m.Drop()
+	m.Close()
+	if err != nil {
+		return err
+	}
+
+	// the dropped migrations table is only created again by a new instance
+	return c.MigrateUp(dsn)
+}
+
+// MigrationStatus describes the migrations folder against the database
+type MigrationStatus struct {
+	Version    uint // current version, 0 when nothing has been applied
+	Dirty      bool // the last migration failed part way through
+	Migrations []MigrationInfo
+}
+
+// MigrationInfo is one migration in the migrations folder
+type MigrationInfo struct {
+	Version uint
+	Name    string
+	Applied bool
+}
+
+// Pending returns the migrations that have not been applied yet
+func (s MigrationStatus) Pending() []MigrationInfo {
+	var pending []MigrationInfo
+	for _, mi := range s.Migrations {
+		if !mi.Applied {
+			pending = append(pending, mi)
+		}
+	}
+	return pending
+}
+
+// MigrationStatus lists every migration, oldest first, with the current
+// version and dirty flag of the database. Migrations run in version order,
+// so every migration up to the current version is applied.
+func (c *RKT) MigrationStatus(dsn string) (*MigrationStatus, error) {
+	m, err := c.newMigrate(dsn)
+	if err != nil {
+		return nil, err
+	}
+	defer m.Close()
+
+	status := &MigrationStatus{}
+	status.Version, status.Dirty, err = m.Version()
+	if err != nil && err != migrate.ErrNilVersion {
+		return nil, err
+	}
+
+	_, src, err := c.openMigrationSource()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+
+	version, err := src.First()
+	for err == nil {
+		name := ""
+		if r, identifier, err := src.ReadUp(version); err == nil {
+			r.Close()
+			name = identifier
+		}
+
+		status.Migrations = append(status.Migrations, MigrationInfo{
+			Version: version,
+			Name:    name,
+			// a dirty version failed, so it isn't counted as applied
+			Applied: version < status.Version || (version == status.Version && !status.Dirty),
+		})
+
+		version, err = src.Next(version)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+// MigrateCommand runs one of the rkt migrate commands: up, down [n|all],
+// to <version>, force <version>, fresh, reset or status, writing the status
+// report to w. Apps with Go migrations call it from the migrate command
+// that rkt make migration --go generates, which rkt migrate then runs.
+func (c *RKT) MigrateCommand(w io.Writer, dsn string, args ...string) error {
+	cmd, arg := "up", ""
+	if len(args) > 0 && args[0] != "" {
+		cmd = args[0]
+	}
+	if len(args) > 1 {
+		arg = args[1]
+	}
+
+	switch cmd {
+	case "up":
+		return c.MigrateUp(dsn)
+
+	case "down":
+		switch arg {
+		case "all":
+			return c.MigrateDownAll(dsn)
+		case "":
+			return c.MigrateDown(1, dsn)
+		}
+		n, err := strconv.Atoi(arg)
+		if err != nil {
+			return fmt.Errorf("migrate down takes a number of migrations or all, got %q", arg)
+		}
+		return c.MigrateDown(n, dsn)
+
+	case "to":
+		version, err := strconv.ParseUint(arg, 10, 64)
+		if err != nil {
+			return fmt.Errorf("migrate to takes a migration version, got %q", arg)
+		}
+		return c.MigrateTo(uint(version), dsn)
+
+	case "force":
+		version, err := strconv.Atoi(arg)
+		if err != nil {
+			return fmt.Errorf("migrate force takes a migration version, or -1 for none, got %q", arg)
+		}
+		return c.MigrateForceVersion(version, dsn)
+
+	case "fresh":
+		return c.MigrateFresh(dsn)
+
+	case "reset":
+		if err := c.MigrateDownAll(dsn); err != nil {
+			return err
+		}
+		return c.MigrateUp(dsn)
+
+	case "status":
+		status, err := c.MigrationStatus(dsn)
+		if err != nil {
+			return err
+		}
+		status.write(w)
+		return nil
+	}
+
+	return fmt.Errorf("unknown migrate command %q", cmd)
+}
+
+// write prints the status report of rkt migrate status
+func (s MigrationStatus) write(w io.Writer) {
+	for _, mi := range s.Migrations {
+		state := "pending"
+		switch {
+		case mi.Applied:
+			state = "applied"
+		case mi.Version == s.Version && s.Dirty:
+			state = "dirty"
+		}
+		fmt.Fprintf(w, "  %-8s %d_%s\n", state, mi.Version, mi.Name)
+	}
+
+	if s.Version == 0 && !s.Dirty {
+		fmt.Fprintln(w, "\nNo migrations have been applied")
+	} else {
+		fmt.Fprintf(w, "\nCurrent version: %d\n", s.Version)
+	}
+	fmt.Fprintf(w, "Pending: %d\n", len(s.Pending()))
+
+	if s.Dirty {
+		fmt.Fprintf(w, "The database is dirty: fix version %d by hand, then run rkt migrate force <version>\n", s.Version)
+	}
+}
@@ -0,0 +1,150 @@
+package rkt
+
+import (
+	"database/sql"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PoolStats is a snapshot of the database connection pool. For mongo only
+// the connection counts are known; the wait and closed counters stay zero.
+type PoolStats struct {
+	Type              string
+	MaxOpen           int
+	Open              int
+	InUse             int
+	Idle              int
+	WaitCount         int64
+	WaitDuration      time.Duration
+	MaxIdleClosed     int64
+	MaxIdleTimeClosed int64
+	MaxLifetimeClosed int64
+}
+
+// LogValue lets PoolStats be logged as a group, e.g.
+// app.Logger.Info("db pool", "pool", app.PoolStats())
+func (s PoolStats) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("type", s.Type),
+		slog.Int("max_open", s.MaxOpen),
+		slog.Int("open", s.Open),
+		slog.Int("in_use", s.InUse),
+		slog.Int("idle", s.Idle),
+		slog.Int64("wait_count", s.WaitCount),
+		slog.Duration("wait_duration", s.WaitDuration),
+		slog.Int64("max_idle_closed", s.MaxIdleClosed),
+		slog.Int64("max_idle_time_closed", s.MaxIdleTimeClosed),
+		slog.Int64("max_lifetime_closed", s.MaxLifetimeClosed),
+	)
+}
+
+// PoolStats reports the state of the database connection pool. It returns
+// the zero value when no database is connected.
+func (r *RKT) PoolStats() PoolStats {
+	switch {
+	case r.DB.Pool != nil:
+		s := r.DB.Pool.Stats()
+		return PoolStats{
+			Type:              r.DB.DataType,
+			MaxOpen:           s.MaxOpenConnections,
+			Open:              s.OpenConnections,
+			InUse:             s.InUse,
+			Idle:              s.Idle,
+			WaitCount:         s.WaitCount,
+			WaitDuration:      s.WaitDuration,
+			MaxIdleClosed:     s.MaxIdleClosed,
+			MaxIdleTimeClosed: s.MaxIdleTimeClosed,
+			MaxLifetimeClosed: s.MaxLifetimeClosed,
+		}
+
+	case r.DB.Conn != nil && r.mongoPool != nil:
+		open := r.mongoPool.open.Load()
+		inUse := r.mongoPool.inUse.Load()
+		maxOpen := r.config.Database.Pool.MaxOpen
+		if maxOpen == 0 {
+			maxOpen = mongoDefaultPoolSize
+		}
+		return PoolStats{
+			Type:    r.DB.DataType,
+			MaxOpen: maxOpen,
+			Open:    int(open),
+			InUse:   int(inUse),
+			Idle:    int(open - inUse),
+		}
+	}
+
+	return PoolStats{}
+}
+
+// sqlPoolDefaults fills in the pool settings left at zero for sql
+// databases, since database/sql on its own opens connections without limit
+// and keeps them forever
+var sqlPoolDefaults = PoolConfig{
+	MaxOpen:     25,
+	MaxIdle:     25,
+	MaxLifetime: 30 * time.Minute,
+	MaxIdleTime: 5 * time.Minute,
+}
+
+// mongoDefaultPoolSize is the mongo driver's pool size, for PoolStats
+const mongoDefaultPoolSize = 100
+
+// applySQL sets the pool limits on db, using sqlPoolDefaults for zero values
+func (p PoolConfig) applySQL(db *sql.DB) {
+	if p.MaxOpen == 0 {
+		p.MaxOpen = sqlPoolDefaults.MaxOpen
+	}
+	if p.MaxIdle == 0 {
+		p.MaxIdle = sqlPoolDefaults.MaxIdle
+	}
+	if p.MaxLifetime == 0 {
+		p.MaxLifetime = sqlPoolDefaults.MaxLifetime
+	}
+	if p.MaxIdleTime == 0 {
+		p.MaxIdleTime = sqlPoolDefaults.MaxIdleTime
+	}
+
+	db.SetMaxOpenConns(p.MaxOpen)
+	db.SetMaxIdleConns(p.MaxIdle)
+	db.SetConnMaxLifetime(p.MaxLifetime)
+	db.SetConnMaxIdleTime(p.MaxIdleTime)
+}
+
+// applyMongo sets the pool limits on the mongo client options, leaving
+// zero values at the driver's default
+func (p PoolConfig) applyMongo(opts *options.ClientOptions) {
+	if p.MaxOpen > 0 {
+		opts.SetMaxPoolSize(uint64(p.MaxOpen))
+	}
+	if p.MaxIdleTime > 0 {
+		opts.SetMaxConnIdleTime(p.MaxIdleTime)
+	}
+}
+
+// mongoPoolMonitor counts connections from the driver's pool events, since
+// the mongo client has no stats call of its own
+type mongoPoolMonitor struct {
+	open  atomic.Int64
+	inUse atomic.Int64
+}
+
+func (m *mongoPoolMonitor) monitor() *event.PoolMonitor {
+	return &event.PoolMonitor{
+		Event: func(e *event.PoolEvent) {
+			switch e.Type {
+			case event.ConnectionCreated:
+				m.open.Add(1)
+			case event.ConnectionClosed:
+				m.open.Add(-1)
+			case event.GetSucceeded:
+				m.inUse.Add(1)
+			case event.ConnectionReturned:
+				m.inUse.Add(-1)
+			}
+		},
+	}
+}
@@ -0,0 +1,46 @@
+package rkt
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestPoolConfig_ApplyMongo(t *testing.T) {
+	// the defaults leave the driver's pool size and idle timeout alone
+	opts := options.Client()
+	DefaultConfig().Database.Pool.applyMongo(opts)
+	if opts.MaxPoolSize != nil || opts.MaxConnIdleTime != nil {
+		t.Errorf("expected the driver defaults, got a pool size of %v and an idle time of %v", opts.MaxPoolSize, opts.MaxConnIdleTime)
+	}
+
+	opts = options.Client()
+	PoolConfig{MaxOpen: 10, MaxIdle: 5, MaxIdleTime: time.Minute}.applyMongo(opts)
+	if opts.MaxPoolSize == nil || *opts.MaxPoolSize != 10 {
+		t.Errorf("expected a pool size of 10, got %v", opts.MaxPoolSize)
+	}
+	if opts.MaxConnIdleTime == nil || *opts.MaxConnIdleTime != time.Minute {
+		t.Errorf("expected an idle time of 1m, got %v", opts.MaxConnIdleTime)
+	}
+}
+
+func TestPoolConfig_ApplySQL(t *testing.T) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "pool.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	DefaultConfig().Database.Pool.applySQL(db)
+	if got := db.Stats().MaxOpenConnections; got != sqlPoolDefaults.MaxOpen {
+		t.Errorf("expected the sql default of %d connections, got %d", sqlPoolDefaults.MaxOpen, got)
+	}
+
+	PoolConfig{MaxOpen: 3}.applySQL(db)
+	if got := db.Stats().MaxOpenConnections; got != 3 {
+		t.Errorf("expected 3 connections, got %d", got)
+	}
+}
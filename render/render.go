@@ -21,6 +21,9 @@ type Render struct {
 	ServerName string
 	JetViews   *jet.Set
 	Session    *scs.SessionManager
+	// OnRender, when set, is called with the view name and template data of
+	// every page just before it is executed
+	OnRender func(view string, data *TemplateData)
 }
 
 type TemplateData struct {
@@ -73,6 +76,10 @@ func (rdr *Render) GoPage(w http.ResponseWriter, r *http.Request, view string, d
 		templateData = data.(*TemplateData)
 	}
 
+	if rdr.OnRender != nil {
+		rdr.OnRender(view, templateData)
+	}
+
 	template.Execute(w, templateData)
 
 	return nil
@@ -95,6 +102,10 @@ func (rdr *Render) JetPage(w http.ResponseWriter, r *http.Request, templateName
 
 	template = rdr.defaultData(template, r)
 
+	if rdr.OnRender != nil {
+		rdr.OnRender(templateName, template)
+	}
+
 	tem, err := rdr.JetViews.GetTemplate(fmt.Sprintf("%s.jet", templateName))
 	if err != nil {
 		log.Println(err)
@@ -0,0 +1,160 @@
+package rkt
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// replicaCheckInterval is how often every replica is pinged
+const replicaCheckInterval = 5 * time.Second
+
+// replicaSet spreads reads over the read replicas that answered their last
+// ping. A background loop keeps pinging, so that a replica that went down is
+// skipped and one that came back is used again.
+type replicaSet struct {
+	replicas []*replica
+	next     atomic.Uint64
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+type replica struct {
+	db      *sql.DB
+	healthy atomic.Bool
+}
+
+// newReplicaSet pings every pool once and starts watching them
+func newReplicaSet(pools []*sql.DB, interval time.Duration) *replicaSet {
+	rs := &replicaSet{stop: make(chan struct{})}
+	for _, db := range pools {
+		rs.replicas = append(rs.replicas, &replica{db: db})
+	}
+	rs.check()
+
+	rs.wg.Add(1)
+	go rs.watch(interval)
+
+	return rs
+}
+
+// pick returns the next healthy replica in turn, or nil when none is healthy
+func (rs *replicaSet) pick() *sql.DB {
+	n := uint64(len(rs.replicas))
+	start := rs.next.Add(1)
+	for i := uint64(0); i < n; i++ {
+		r := rs.replicas[(start+i)%n]
+		if r.healthy.Load() {
+			return r.db
+		}
+	}
+	return nil
+}
+
+func (rs *replicaSet) watch(interval time.Duration) {
+	defer rs.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			rs.check()
+		case <-rs.stop:
+			return
+		}
+	}
+}
+
+// check pings every replica and records which ones answered
+func (rs *replicaSet) check() {
+	var wg sync.WaitGroup
+	for _, r := range rs.replicas {
+		wg.Add(1)
+		go func(r *replica) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), replicaCheckInterval)
+			defer cancel()
+			r.healthy.Store(r.db.PingContext(ctx) == nil)
+		}(r)
+	}
+	wg.Wait()
+}
+
+// close stops the health checks and closes every replica pool
+func (rs *replicaSet) close() error {
+	close(rs.stop)
+	rs.wg.Wait()
+
+	var errs []error
+	for _, r := range rs.replicas {
+		if err := r.db.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Writer returns the primary pool, which takes every write
+func (d Database) Writer() *sql.DB {
+	return d.Pool
+}
+
+// Reader returns a read replica, taking healthy replicas in turn, and falls
+// back to the primary when there are no replicas or none of them is up
+func (d Database) Reader() *sql.DB {
+	if d.replicas != nil {
+		if db := d.replicas.pick(); db != nil {
+			return db
+		}
+	}
+	return d.Pool
+}
+
+// Replicas returns every read replica pool, healthy or not
+func (d Database) Replicas() []*sql.DB {
+	if d.replicas == nil {
+		return nil
+	}
+
+	pools := make([]*sql.DB, len(d.replicas.replicas))
+	for i, r := range d.replicas.replicas {
+		pools[i] = r.db
+	}
+	return pools
+}
+
+// openReplicas opens a pool for each configured replica. A replica that is
+// down at boot is not an error; it is skipped until it answers a ping.
+func (r *RKT) openReplicas() (*replicaSet, error) {
+	dsns := r.config.Database.ReplicaDSNs()
+	if len(dsns) == 0 {
+		return nil, nil
+	}
+
+	var pools []*sql.DB
+	for _, dsn := range dsns {
+		db, err := r.openPool(r.config.Database.Type, dsn)
+		if err != nil {
+			for _, p := range pools {
+				_ = p.Close()
+			}
+			return nil, err
+		}
+		pools = append(pools, db)
+	}
+
+	rs := newReplicaSet(pools, replicaCheckInterval)
+	for i, rep := range rs.replicas {
+		if !rep.healthy.Load() {
+			r.Logger.Warn("Read replica is not reachable, reads go elsewhere until it is", "replica", i, "dsn", RedactDSN(dsns[i]))
+		}
+	}
+
+	return rs, nil
+}
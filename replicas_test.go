@@ -0,0 +1,57 @@
+package rkt
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDatabase_Reader(t *testing.T) {
+	dir := t.TempDir()
+	open := func(name string) *sql.DB {
+		db, err := sql.Open("sqlite", filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return db
+	}
+
+	primary := open("primary.sqlite")
+	defer primary.Close()
+
+	d := Database{DataType: "sqlite", Pool: primary}
+	if d.Reader() != primary {
+		t.Error("expected reads to go to the primary without replicas")
+	}
+
+	a, b := open("a.sqlite"), open("b.sqlite")
+	d.replicas = newReplicaSet([]*sql.DB{a, b}, time.Hour)
+	defer d.replicas.close()
+
+	seen := map[*sql.DB]int{}
+	for i := 0; i < 4; i++ {
+		seen[d.Reader()]++
+	}
+	if seen[a] != 2 || seen[b] != 2 {
+		t.Errorf("expected reads to alternate between replicas, got a=%d b=%d primary=%d", seen[a], seen[b], seen[primary])
+	}
+	if d.Writer() != primary {
+		t.Error("expected writes to go to the primary")
+	}
+
+	// a closed pool fails its ping, like a replica that went down
+	_ = a.Close()
+	d.replicas.check()
+	for i := 0; i < 3; i++ {
+		if d.Reader() != b {
+			t.Fatal("expected reads to skip the replica that is down")
+		}
+	}
+
+	_ = b.Close()
+	d.replicas.check()
+	if d.Reader() != primary {
+		t.Error("expected reads to fall back to the primary when every replica is down")
+	}
+}
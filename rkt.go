@@ -3,12 +3,17 @@ package rkt
 import (
 	"context"
 	"fmt"
+	"io"
+	"io/fs"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/CloudyKit/jet/v6"
@@ -22,16 +27,12 @@ import (
 	"github.com/m-goku/rkt/render"
 	"github.com/m-goku/rkt/sessions"
 	"github.com/robfig/cron/v3"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/connstring"
 )
 
 const version = "1.0.0"
 
-var myRedisCache *cache.RedisCache
-var myBadgerCache *cache.BadgerCache
-
-var redisPool *redis.Pool
-var badgerConn *badger.DB
-
 /*
 Create the Rocket struct
 AppName - the name of the app
@@ -42,29 +43,41 @@ type RKT struct {
 	AppName       string
 	Debug         bool
 	Version       string
+	Logger        *slog.Logger
 	ErrorLog      *log.Logger
 	InfoLog       *log.Logger
 	RootPath      string
 	Routes        *chi.Mux
+	handler       http.Handler
 	Render        *render.Render
 	Session       *scs.SessionManager
 	DB            Database
 	JetViews      *jet.Set
-	config        config
+	config        Config
 	EncryptionKey string
 	Cache         cache.Cache
 	Scheduler     *cron.Cron
 	Mail          mailer.Mail
 	Server        Server
-}
-
-type config struct {
-	port        string
-	renderer    string
-	cookie      cookieConfig
-	sessionType string
-	database    databaseConfig
-	redis       redisConfig
+	server        *http.Server
+	redirectSrv   *http.Server
+	bootHooks     []Hook
+	startHooks    []Hook
+	shutdownHooks []Hook
+	services      []namedService
+	servicesMu    sync.RWMutex
+	started       []namedService
+	logFile       io.Closer
+	healthChecks  []namedCheck
+	shuttingDown  atomic.Bool
+	metrics       *metrics
+	mongoPool     *mongoPoolMonitor
+	migrationsFS  fs.FS
+	goMigrations  Migrations
+	redisPool     *redis.Pool
+	badgerConn    *badger.DB
+	closeOnce     sync.Once
+	closeErrs     []error
 }
 
 type Server struct {
@@ -76,11 +89,37 @@ type Server struct {
 
 /*
 New method is to create an instance of the Rocket struct.
-It has the Init method that that takes the path and create -
-the neccessary folders for the project
-pathConfig is a variable for the instance of the initPath struct
+It loads the .env file in rootPath, reads the configuration with LoadConfig
+and hands it to NewWithConfig
 */
 func (r *RKT) New(rootPath string) error {
+	//check for .env file
+	// Load .env only if running locally (not on Render)
+	if os.Getenv("RENDER") == "" {
+		if err := godotenv.Load(filepath.Join(rootPath, ".env")); err != nil {
+			log.Println("⚠️ No .env file found, skipping...")
+		} else {
+			log.Println("✅ Loaded .env file for local development")
+		}
+	} else {
+		log.Println("✅ Running on Render - using environment variables")
+	}
+
+	cfg, err := LoadConfig(rootPath)
+	if err != nil {
+		return err
+	}
+
+	return r.NewWithConfig(rootPath, cfg)
+}
+
+/*
+NewWithConfig creates an instance of the Rocket struct from cfg without
+reading the environment. It has the Init method that that takes the path
+and create the neccessary folders for the project
+pathConfig is a variable for the instance of the initPath struct
+*/
+func (r *RKT) NewWithConfig(rootPath string, cfg Config) error {
 	pathConfig := initPaths{
 		rootPath: rootPath,
 		folderNames: []string{
@@ -96,146 +135,162 @@ func (r *RKT) New(rootPath string) error {
 		},
 	}
 
-	//initialize path and create folders
-	err := r.Init(pathConfig)
+	// check the whole configuration before touching anything
+	err := cfg.validate(r.DB.Pool != nil)
 	if err != nil {
 		return err
 	}
 
-	//check for .env file
-	// Load .env only if running locally (not on Render)
-	if os.Getenv("RENDER") == "" {
-		if err := godotenv.Load(filepath.Join(rootPath, ".env")); err != nil {
-			log.Println("⚠️ No .env file found, skipping...")
-		} else {
-			log.Println("✅ Loaded .env file for local development")
-		}
-	} else {
-		log.Println("✅ Running on Render - using environment variables")
+	//initialize path and create folders
+	err = r.Init(pathConfig)
+	if err != nil {
+		return err
 	}
 
-	// load .env into the go environment of the app
+	r.config = cfg
+	r.RootPath = rootPath
+	r.config.Server.TLSCertFile = r.absPath(cfg.Server.TLSCertFile)
+	r.config.Server.TLSKeyFile = r.absPath(cfg.Server.TLSKeyFile)
+	r.config.Database.ResolvePath(rootPath)
 
 	//create loggers
-	infoLog, errorLog := r.startLoggers()
+	r.startLoggers()
+
+	// every backend is tried, so that all failures are reported together
+	var problems []string
 
 	// connect to database
-	if os.Getenv("DATABASE_TYPE") != "" {
-		if os.Getenv("DATABASE_TYPE") == "mongodb" {
+	if cfg.Database.Type != "" {
+		if cfg.Database.Type == "mongodb" {
 			mongoClient, err := r.OpenMongoDB(r.BuildDSN())
 			if err != nil {
-				errorLog.Println(err)
-				os.Exit(1)
-			}
-			//defer mongoClient.Disconnect(context.Background())
-
-			r.DB = Database{
-				DataType: os.Getenv("DATABASE_TYPE"),
-				Conn:     mongoClient,
+				problems = append(problems, fmt.Sprintf("database: could not connect to MongoDB at %s: %v", r.config.Database.RedactedDSN(), r.config.Database.RedactError(err)))
+			} else {
+				r.DB = Database{
+					DataType: cfg.Database.Type,
+					Conn:     mongoClient,
+				}
+
+				r.Logger.Info("Connected to MongoDB!", "dsn", r.config.Database.RedactedDSN())
 			}
 
-			infoLog.Println("Connected to MongoDB!")
+		} else if r.DB.Pool != nil {
+			// a pool handed in before NewWithConfig, as rkttest.WithDatabase
+			// does, is used instead of opening one
+			r.DB.DataType = cfg.Database.Type
+			r.Logger.Info("Using the database pool handed to the app", "type", cfg.Database.Type)
 
-		} else if os.Getenv("DATABASE_TYPE") == "postgresql" || os.Getenv("DATABASE_TYPE") == "postgres" {
-			db, err := r.OpenPostgresDB(os.Getenv("DATABASE_TYPE"), r.BuildDSN())
+		} else {
+			db, err := r.OpenDB(r.config.Database.Type, r.BuildDSN())
 			if err != nil {
-				errorLog.Println(err)
-				os.Exit(1)
+				problems = append(problems, fmt.Sprintf("database: could not connect to %s at %s: %v", cfg.Database.Type, r.config.Database.RedactedDSN(), r.config.Database.RedactError(err)))
+			} else {
+				r.DB = Database{
+					DataType: cfg.Database.Type,
+					Pool:     db,
+				}
+				r.Logger.Info("Connected to database", "type", cfg.Database.Type, "dsn", r.config.Database.RedactedDSN())
+
+				r.DB.replicas, err = r.openReplicas()
+				if err != nil {
+					problems = append(problems, fmt.Sprintf("database: could not open read replicas: %v", r.config.Database.RedactError(err)))
+				}
 			}
-			r.DB = Database{
-				DataType: os.Getenv("DATABASE_TYPE"),
-				Pool:     db,
+		}
+
+		// migrate before the sessions, jobs and boot hooks touch the schema
+		if cfg.Database.MigrateOnStart && (r.DB.Pool != nil || r.DB.Conn != nil) {
+			err := r.migrateOnStart(context.Background())
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("migrations: %v", r.config.Database.RedactError(err)))
 			}
-			infoLog.Println("Connected to Postgresql!")
 		}
 	}
 
 	scheduler := cron.New()
 	r.Scheduler = scheduler
 
-	if os.Getenv("CACHE") == "redis" || os.Getenv("SESSION_TYPE") == "redis" {
-		myRedisCache = r.createClientRedisCache()
-		r.Cache = myRedisCache
-		redisPool = myRedisCache.Conn
+	if cfg.Cache.Type == "redis" || cfg.Session.Type == "redis" {
+		redisCache := r.createClientRedisCache()
+		r.Cache = redisCache
+		r.redisPool = redisCache.Conn
 	}
 
-	if os.Getenv("CACHE") == "badger" {
-		myBadgerCache = r.createClientBadgerCache()
-		r.Cache = myBadgerCache
-		badgerConn = myBadgerCache.Conn
+	if cfg.Cache.Type == "memory" {
+		r.Cache = cache.NewMemoryCache()
+	}
 
-		_, err = r.Scheduler.AddFunc("@daily", func() {
-			_ = myBadgerCache.Conn.RunValueLogGC(0.7)
-		})
+	if cfg.Cache.Type == "badger" {
+		badgerCache, err := r.createClientBadgerCache()
 		if err != nil {
-			return err
+			problems = append(problems, fmt.Sprintf("cache: could not open badger database: %v", err))
+		} else {
+			r.Cache = badgerCache
+			r.badgerConn = badgerCache.Conn
+
+			_, err = r.Scheduler.AddFunc("@daily", func() {
+				_ = badgerCache.Conn.RunValueLogGC(0.7)
+			})
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("cache: could not schedule badger garbage collection: %v", err))
+			}
 		}
 	}
 
-	r.InfoLog = infoLog
-	r.ErrorLog = errorLog
-	r.Debug, _ = strconv.ParseBool(os.Getenv("DEBUG"))
-	r.Version = version
-	r.RootPath = rootPath
-	r.Mail = r.createMailer()
-	//set routes
-	r.Routes = r.routes().(*chi.Mux)
-
-	//set configurations
-	r.config = config{
-		port:     os.Getenv("PORT"),
-		renderer: os.Getenv("RENDERER"),
-		cookie: cookieConfig{
-			name:     os.Getenv("COOKIE_NAME"),
-			lifetime: os.Getenv("COOKIE_LIFETIME"),
-			persists: os.Getenv("COOKIE_PERSISTS"),
-			secure:   os.Getenv("COOKIE_SECURE"),
-			domain:   os.Getenv("COOKIE_DOMAIN"),
-		},
-		sessionType: os.Getenv("SESSION_TYPE"),
-		database: databaseConfig{
-			database: os.Getenv("DATABASE_TYPE"),
-			dsn:      r.BuildDSN(),
-		},
-		redis: redisConfig{
-			host:     os.Getenv("REDIS_HOST"),
-			password: os.Getenv("REDIS_PASSWORD"),
-			prefix:   os.Getenv("REDIS_PREFIX"),
-		},
+	// r.Server is not set yet, so tlsEnabled can't answer this
+	if cfg.Server.Secure && cfg.Server.TLSCertFile != "" && cfg.Server.TLSKeyFile != "" {
+		for _, file := range []string{r.config.Server.TLSCertFile, r.config.Server.TLSKeyFile} {
+			if _, err := os.Stat(file); err != nil {
+				problems = append(problems, fmt.Sprintf("tls: %v", err))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		r.abortBoot()
+		return &ConfigError{Problems: problems}
 	}
 
-	secure := true
-	if strings.ToLower(os.Getenv("SECURE")) == "false" {
-		secure = false
+	r.AppName = cfg.AppName
+	r.Debug = cfg.Debug
+	r.Version = version
+	r.Mail = r.createMailer()
+	if cfg.Metrics.Enabled {
+		r.startMetrics()
 	}
+	//set routes
+	r.Routes = r.routes()
+	r.handler = r.endpoints(r.Routes)
 
 	r.Server = Server{
-		ServerName: os.Getenv("SERVER_NAME"),
-		Port:       os.Getenv("PORT"),
-		Secure:     secure,
-		URL:        os.Getenv("APP_URL"),
+		ServerName: cfg.Server.Name,
+		Port:       cfg.Server.Port,
+		Secure:     cfg.Server.Secure,
+		URL:        cfg.Server.URL,
 	}
 
 	// create session
 	session := sessions.Session{
-		CookieLifetime: r.config.cookie.lifetime,
-		CookiePersist:  r.config.cookie.persists,
-		CookieName:     r.config.cookie.name,
-		CookieDomain:   r.config.cookie.domain,
-		CookieSecure:   r.config.cookie.secure,
-		SessionType:    r.config.sessionType,
+		CookieLifetime: strconv.Itoa(cfg.Cookie.Lifetime),
+		CookiePersist:  strconv.FormatBool(cfg.Cookie.Persist),
+		CookieName:     cfg.Cookie.Name,
+		CookieDomain:   cfg.Cookie.Domain,
+		CookieSecure:   strconv.FormatBool(cfg.Cookie.Secure),
+		SessionType:    cfg.Session.Type,
 		DBPool:         r.DB.Pool,
 	}
 
-	switch r.config.sessionType {
+	switch cfg.Session.Type {
 	case "redis":
-		session.RedisPool = myRedisCache.Conn
-	case "mysql", "postgres", "mariadb", "postgresql":
+		session.RedisPool = r.redisPool
+	case "mysql", "postgres", "mariadb", "postgresql", "sqlite":
 		session.DBPool = r.DB.Pool
+	case "mongodb":
+		session.MongoDB = r.MongoDatabase()
 	}
 
 	r.Session = session.InitSession()
-	r.EncryptionKey = os.Getenv("KEY")
+	r.EncryptionKey = cfg.Key
 
 	if r.Debug {
 		var views = jet.NewSet(
@@ -252,9 +307,35 @@ func (r *RKT) New(rootPath string) error {
 
 	r.createRenderer()
 
+	err = r.runBootHooks(context.Background())
+	if err != nil {
+		r.abortBoot()
+		return err
+	}
+
 	return nil
 }
 
+// abortBoot releases whatever NewWithConfig opened before it failed, and
+// forgets the closed handles so that NewWithConfig can be tried again on the
+// same instance
+func (r *RKT) abortBoot() {
+	_ = r.closeBackends(context.Background())
+	if r.logFile != nil {
+		_ = r.logFile.Close()
+	}
+
+	r.DB = Database{}
+	r.Session = nil
+	r.Cache = nil
+	r.redisPool = nil
+	r.badgerConn = nil
+	r.mongoPool = nil
+	r.logFile = nil
+	r.closeOnce = sync.Once{}
+	r.closeErrs = nil
+}
+
 /*
 Init method that that takes the initPath struct and create -
 the neccessary folders for the project into the path provided
@@ -271,55 +352,15 @@ func (r *RKT) Init(p initPaths) error {
 	return nil
 }
 
-// Listen and serve starts the web server
-func (r *RKT) ListenAndServe() {
-	server := &http.Server{
-		Addr:         ":" + os.Getenv("PORT"),
-		ErrorLog:     r.ErrorLog,
-		Handler:      r.Routes,
-		IdleTimeout:  30 * time.Second,
-		ReadTimeout:  30 * time.Second,
-		WriteTimeout: 600 * time.Second,
-	}
-
-	//close database connections
-	if r.DB.Conn != nil {
-		defer r.DB.Conn.Disconnect(context.Background())
-	}
-
-	if r.DB.Pool != nil {
-		defer r.DB.Pool.Close()
-	}
-
-	if redisPool != nil {
-		defer redisPool.Close()
-	}
-
-	if badgerConn != nil {
-		defer badgerConn.Close()
-	}
-
-	r.InfoLog.Println("Listening on Port" + os.Getenv("PORT"))
-	err := server.ListenAndServe()
-	r.ErrorLog.Fatal(err)
-}
-
-func (r *RKT) startLoggers() (*log.Logger, *log.Logger) {
-	var infoLog *log.Logger
-	var errorLog *log.Logger
-
-	infoLog = log.New(os.Stdout, "INFO\t", log.Ldate|log.Ltime)
-	errorLog = log.New(os.Stdout, "ERROR\t", log.Ldate|log.Ltime|log.Lshortfile)
-	return infoLog, errorLog
-}
-
 func (r *RKT) createRenderer() {
 	myRenderer := &render.Render{
-		Renderer: r.config.renderer,
-		RootPath: r.RootPath,
-		Port:     r.config.port,
-		JetViews: r.JetViews,
-		Session:  r.Session,
+		Renderer:   r.config.Renderer.Engine,
+		RootPath:   r.RootPath,
+		Secure:     r.config.Server.Secure,
+		Port:       r.config.Server.Port,
+		ServerName: r.config.Server.Name,
+		JetViews:   r.JetViews,
+		Session:    r.Session,
 	}
 	r.Render = myRenderer
 }
@@ -328,10 +369,10 @@ func (c *RKT) createMailer() mailer.Mail {
 	//port, _ := strconv.Atoi(os.Getenv("SMTP_PORT"))
 	m := mailer.Mail{
 		Templates:   c.RootPath + "/mail",
-		FromName:    os.Getenv("FROM_NAME"),
-		FromAddress: os.Getenv("FROM_ADDRESS"),
-		PublicAPI:   os.Getenv("PUBLIC_API"),
-		PrivateAPI:  os.Getenv("PRIVATE_API"),
+		FromName:    c.config.Mail.FromName,
+		FromAddress: c.config.Mail.FromAddress,
+		PublicAPI:   c.config.Mail.PublicAPI,
+		PrivateAPI:  c.config.Mail.PrivateAPI,
 		// Domain:      os.Getenv("MAIL_DOMAIN"),
 		//Host:        os.Getenv("SMTP_HOST"),
 		//Port:        port,
@@ -347,31 +388,136 @@ func (c *RKT) createMailer() mailer.Mail {
 
 // BuildDSN builds the datasource name for our database, and returns it as a string
 func (c *RKT) BuildDSN() string {
-	var dsn string
-
-	switch os.Getenv("DATABASE_TYPE") {
-	case "postgres", "postgresql", "mongodb", "mongo":
-		dsn = os.Getenv("DATABASE_CONN_STR")
-		// dsn = fmt.Sprintf("host=%s port=%s user=%s dbname=%s sslmode=%s timezone=UTC connect_timeout=5",
-		// 	os.Getenv("DATABASE_HOST"),
-		// 	os.Getenv("DATABASE_PORT"),
-		// 	os.Getenv("DATABASE_USER"),
-		// 	os.Getenv("DATABASE_NAME"),
-		// 	os.Getenv("DATABASE_SSL_MODE"))
-
-		// we check to see if a database passsword has been supplied, since including "password=" with nothing
-		// after it sometimes causes postgres to fail to allow a connection.
-		// if os.Getenv("DATABASE_PASS") != "" {
-		// 	dsn = fmt.Sprintf("%s password=%s", dsn, os.Getenv("DATABASE_PASS"))
-		// }
+	return c.config.Database.BuildDSN()
+}
+
+// MigrationDSN returns the database url golang-migrate expects
+func (c *RKT) MigrationDSN() string {
+	return c.config.Database.MigrationDSN()
+}
+
+// BuildDSN builds the datasource name for the configured database type
+func (d DatabaseConfig) BuildDSN() string {
+	dsn := d.DSN
+	if dsn == "" {
+		dsn = d.connString()
+	}
+
+	switch d.Type {
+	case "postgres", "postgresql", "pgx", "mongodb", "mongo":
+
+	case "mysql", "mariadb":
+		// the mysql driver takes user:pass@tcp(host:port)/db, without a scheme
+		dsn = strings.TrimPrefix(dsn, "mysql://")
+		if dsn != "" {
+			dsn = withDSNParam(dsn, "parseTime", "true")
+		}
+
+	case "sqlite":
+		// foreign keys are off by default in sqlite, and the busy timeout keeps
+		// concurrent writers from failing straight away with SQLITE_BUSY
+		dsn = strings.TrimPrefix(dsn, "sqlite://")
+		if dsn != "" && !strings.Contains(dsn, "?") {
+			dsn += "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+		}
 
 	default:
+		dsn = ""
+	}
+
+	return dsn
+}
 
+// MigrationDSN returns the database url golang-migrate expects. It is the
+// same as BuildDSN, except that mysql needs a mysql:// scheme and multi
+// statement support for migration files holding several statements.
+func (d DatabaseConfig) MigrationDSN() string {
+	dsn := d.BuildDSN()
+
+	switch d.Type {
+	case "mysql", "mariadb":
+		if dsn != "" {
+			dsn = mysqlMigrationURL(dsn)
+		}
+	case "sqlite":
+		if dsn != "" {
+			dsn = "sqlite://" + dsn
+		}
 	}
 
 	return dsn
 }
 
+// MongoDatabase returns the mongodb database named in the connection string,
+// or nil when mongodb is not connected
+func (c *RKT) MongoDatabase() *mongo.Database {
+	if c.DB.Conn == nil {
+		return nil
+	}
+	return c.DB.Conn.Database(c.config.Database.MongoDatabase())
+}
+
+// MongoDatabase returns the database named in a mongodb connection string,
+// or "" when it names none
+func (d DatabaseConfig) MongoDatabase() string {
+	cs, err := connstring.Parse(d.BuildDSN())
+	if err != nil {
+		return ""
+	}
+	return cs.Database
+}
+
+// ReplicaDSNs builds the datasource name of each read replica
+func (d DatabaseConfig) ReplicaDSNs() []string {
+	var dsns []string
+	for _, replica := range d.Replicas {
+		dsns = append(dsns, DatabaseConfig{Type: d.Type, DSN: replica}.BuildDSN())
+	}
+	return dsns
+}
+
+// ResolvePath points a sqlite database at its file under rootPath. A
+// relative DATABASE_CONN_STR is taken to be relative to the project root,
+// and an empty one falls back to DATABASE_NAME and then to db.sqlite.
+func (d *DatabaseConfig) ResolvePath(rootPath string) {
+	if d.Type != "sqlite" {
+		return
+	}
+
+	path, query, _ := strings.Cut(strings.TrimPrefix(d.DSN, "sqlite://"), "?")
+	if path == "" {
+		path = d.Name
+	}
+	if path == "" {
+		path = defaultSQLiteFile
+	}
+	if path != ":memory:" && !filepath.IsAbs(path) {
+		path = filepath.Join(rootPath, path)
+	}
+
+	d.DSN = path
+	if query != "" {
+		d.DSN += "?" + query
+	}
+}
+
+// withDSNParam adds key=value to the query of dsn, unless key is already set
+func withDSNParam(dsn, key, value string) string {
+	base, query, found := dsn, "", false
+	if i := dsnQueryIndex(dsn); i >= 0 {
+		base, query, found = dsn[:i], dsn[i+1:], true
+	}
+	if found {
+		for _, param := range strings.Split(query, "&") {
+			if k, _, _ := strings.Cut(param, "="); k == key {
+				return dsn
+			}
+		}
+		return base + "?" + query + "&" + key + "=" + value
+	}
+	return dsn + "?" + key + "=" + value
+}
+
 func (c *RKT) createRedisPool() *redis.Pool {
 	return &redis.Pool{
 		MaxIdle:     50,
@@ -379,8 +525,8 @@ func (c *RKT) createRedisPool() *redis.Pool {
 		IdleTimeout: 240 * time.Second,
 		Dial: func() (redis.Conn, error) {
 			return redis.Dial("tcp",
-				c.config.redis.host,
-				redis.DialPassword(c.config.redis.password))
+				c.config.Cache.Redis.Host,
+				redis.DialPassword(c.config.Cache.Redis.Password))
 		},
 
 		TestOnBorrow: func(conn redis.Conn, t time.Time) error {
@@ -393,22 +539,27 @@ func (c *RKT) createRedisPool() *redis.Pool {
 func (c *RKT) createClientRedisCache() *cache.RedisCache {
 	cacheClient := cache.RedisCache{
 		Conn:   c.createRedisPool(),
-		Prefix: c.config.redis.prefix,
+		Prefix: c.config.Cache.Redis.Prefix,
 	}
 	return &cacheClient
 }
 
-func (c *RKT) createClientBadgerCache() *cache.BadgerCache {
+func (c *RKT) createClientBadgerCache() (*cache.BadgerCache, error) {
+	conn, err := c.createBadgerConn()
+	if err != nil {
+		return nil, err
+	}
+
 	cacheClient := cache.BadgerCache{
-		Conn: c.createBadgerConn(),
+		Conn: conn,
 	}
-	return &cacheClient
+	return &cacheClient, nil
 }
 
-func (c *RKT) createBadgerConn() *badger.DB {
+func (c *RKT) createBadgerConn() (*badger.DB, error) {
 	db, err := badger.Open(badger.DefaultOptions(c.RootPath + "/tmp/badger"))
 	if err != nil {
-		return nil
+		return nil, err
 	}
-	return db
+	return db, nil
 }
@@ -0,0 +1,193 @@
+package rkt
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// testConfig returns the config tests boot with, a valid key and quiet logs,
+// after edit has had its way with it
+func testConfig(edit func(*Config)) Config {
+	cfg := DefaultConfig()
+	cfg.Key = "abcdefghijklmnopqrstuvwxyz012345"
+	cfg.Log.Level = "error"
+	if edit != nil {
+		edit(&cfg)
+	}
+	return cfg
+}
+
+// newTestApp boots an app in a temp folder and closes it when the test ends
+func newTestApp(t *testing.T, edit func(*Config)) *RKT {
+	t.Helper()
+	return newTestAppIn(t, t.TempDir(), edit)
+}
+
+// newTestAppIn boots an app in root and closes it when the test ends
+func newTestAppIn(t *testing.T, root string, edit func(*Config)) *RKT {
+	t.Helper()
+
+	app := &RKT{}
+	if err := app.NewWithConfig(root, testConfig(edit)); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = app.Close() })
+	return app
+}
+
+// withSQLite points the database at db.sqlite in the app's folder
+func withSQLite(cfg *Config) {
+	cfg.Database.Type = "sqlite"
+}
+
+func TestRKT_IndependentInstances(t *testing.T) {
+	apps := make([]*RKT, 3)
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(apps))
+	for i := range apps {
+		apps[i] = &RKT{}
+		cfg := testConfig(func(cfg *Config) { cfg.Cache.Type = "badger" })
+		root := t.TempDir()
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = apps[i].NewWithConfig(root, cfg)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("instance %d: %v", i, err)
+		}
+	}
+
+	for i, app := range apps {
+		if err := app.Cache.Set("owner", fmt.Sprintf("app %d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i, app := range apps {
+		owner, err := app.Cache.Get("owner")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if owner != fmt.Sprintf("app %d", i) {
+			t.Errorf("instance %d sees cache value %v from another instance", i, owner)
+		}
+	}
+
+	// closing one instance must leave the others usable
+	if err := apps[0].Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := apps[1].Cache.Get("owner"); err != nil {
+		t.Errorf("expected instance 1 to still be open, got %v", err)
+	}
+
+	for _, app := range apps[1:] {
+		if err := app.Close(); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// a second close is a no-op
+	if err := apps[0].Close(); err != nil {
+		t.Errorf("expected closing twice to succeed, got %v", err)
+	}
+}
+
+func TestRKT_RetryAfterFailedBoot(t *testing.T) {
+	root := t.TempDir()
+	cfg := testConfig(func(cfg *Config) {
+		withSQLite(cfg)
+		cfg.Cache.Type = "badger"
+		cfg.Server.TLSCertFile = "missing.crt"
+		cfg.Server.TLSKeyFile = "missing.key"
+	})
+
+	app := &RKT{}
+	if err := app.NewWithConfig(root, cfg); err == nil {
+		t.Fatal("expected the missing certificate files to fail the boot")
+	}
+
+	cfg.Server.Secure = false
+	if err := app.NewWithConfig(root, cfg); err != nil {
+		t.Fatalf("expected the retry to boot, got %v", err)
+	}
+	if err := app.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// badger holds a lock on its folder until it is closed
+	again := &RKT{}
+	if err := again.NewWithConfig(root, cfg); err != nil {
+		t.Fatalf("expected the retried instance to have released its backends, got %v", err)
+	}
+	if err := again.Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRKT_SQLite(t *testing.T) {
+	app := newTestApp(t, func(cfg *Config) {
+		withSQLite(cfg)
+		cfg.Session.Type = "sqlite"
+	})
+	root := app.RootPath
+
+	for name, template := range map[string]string{
+		"1_create_sessions_table.up.sql": "sqlite_session.sql",
+		"2_create_auth_tables.up.sql":    "auth_tables.sqlite.sql",
+	} {
+		sql, err := os.ReadFile(filepath.Join("cmd", "cli", "templates", "migrations", template))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, "migrations", name), sql, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "db.sqlite")); err != nil {
+		t.Fatalf("expected the database file in the project root: %v", err)
+	}
+
+	if stats := app.PoolStats(); stats.Type != "sqlite" || stats.MaxOpen != sqlPoolDefaults.MaxOpen {
+		t.Errorf("expected pool stats for a sqlite pool of %d, got %+v", sqlPoolDefaults.MaxOpen, stats)
+	}
+
+	if err := app.MigrateUp(app.MigrationDSN()); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := app.DB.Pool.Exec("insert into users (first_name, last_name, email, password) values ('a', 'b', 'a@example.com', 'x')")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, err := app.Session.Load(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.Session.Put(ctx, "userID", 1)
+	token, _, err := app.Session.Commit(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, err = app.Session.Load(context.Background(), token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := app.Session.GetInt(ctx, "userID"); got != 1 {
+		t.Errorf("expected the session to be stored in sqlite, got userID %d", got)
+	}
+}
@@ -0,0 +1,263 @@
+package rkttest
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/justinas/nosurf"
+)
+
+// csrfTokenLength is the length of the raw token nosurf keeps in its cookie
+const csrfTokenLength = 32
+
+// Client sends requests to the app through a real http server. It keeps
+// cookies between requests, does not follow redirects, and adds a valid
+// CSRF token to every request that NoSurf checks.
+type Client struct {
+	app    *App
+	t      testing.TB
+	server *httptest.Server
+	http   *http.Client
+}
+
+// Response is a finished response with its body already read
+type Response struct {
+	*http.Response
+	t    testing.TB
+	body []byte
+}
+
+// Client starts a test server for the app's routes. Every client has its
+// own cookie jar, so two clients act as two different browsers.
+func (a *App) Client() *Client {
+	a.t.Helper()
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		a.t.Fatalf("rkttest: %v", err)
+	}
+
+	server := httptest.NewServer(a.Handler())
+	a.t.Cleanup(server.Close)
+
+	return &Client{
+		app:    a,
+		t:      a.t,
+		server: server,
+		http: &http.Client{
+			Jar: jar,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// URL returns the absolute url of path on the test server
+func (c *Client) URL(path string) string {
+	return c.server.URL + path
+}
+
+// Get sends a GET request
+func (c *Client) Get(path string) *Response {
+	c.t.Helper()
+	return c.Do(c.newRequest(http.MethodGet, path, nil, ""))
+}
+
+// PostForm sends a url encoded form
+func (c *Client) PostForm(path string, data url.Values) *Response {
+	c.t.Helper()
+	return c.Do(c.newRequest(http.MethodPost, path, strings.NewReader(data.Encode()), "application/x-www-form-urlencoded"))
+}
+
+// PostJSON sends v encoded as JSON
+func (c *Client) PostJSON(path string, v any) *Response {
+	c.t.Helper()
+
+	body, err := json.Marshal(v)
+	if err != nil {
+		c.t.Fatalf("rkttest: encoding json: %v", err)
+	}
+	return c.Do(c.newRequest(http.MethodPost, path, bytes.NewReader(body), "application/json"))
+}
+
+// Do sends req, adding the CSRF token and the same origin header that NoSurf
+// expects on unsafe methods
+func (c *Client) Do(req *http.Request) *Response {
+	c.t.Helper()
+
+	if req.URL.Host == "" {
+		u, err := url.Parse(c.URL(req.URL.String()))
+		if err != nil {
+			c.t.Fatalf("rkttest: %v", err)
+		}
+		req.URL = u
+		req.Host = u.Host
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+	default:
+		req.Header.Set(nosurf.HeaderName, c.maskedCSRFToken())
+		req.Header.Set("Sec-Fetch-Site", "same-origin")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		c.t.Fatalf("rkttest: %s %s: %v", req.Method, req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		c.t.Fatalf("rkttest: reading body of %s %s: %v", req.Method, req.URL.Path, err)
+	}
+
+	return &Response{Response: resp, t: c.t, body: body}
+}
+
+func (c *Client) newRequest(method, path string, body io.Reader, contentType string) *http.Request {
+	c.t.Helper()
+
+	req, err := http.NewRequest(method, c.URL(path), body)
+	if err != nil {
+		c.t.Fatalf("rkttest: %v", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return req
+}
+
+/*
+maskedCSRFToken returns the token to send in the X-CSRF-Token header. NoSurf
+keeps the raw token in a cookie and only accepts it back masked with a one
+time pad, the way nosurf.Token hands it to templates. When the jar has no
+token cookie yet, the client makes one up, which NoSurf accepts just the same.
+*/
+func (c *Client) maskedCSRFToken() string {
+	c.t.Helper()
+
+	u, _ := url.Parse(c.server.URL)
+
+	var token []byte
+	for _, cookie := range c.http.Jar.Cookies(u) {
+		if cookie.Name == nosurf.CookieName {
+			token, _ = base64.StdEncoding.DecodeString(cookie.Value)
+		}
+	}
+
+	if len(token) != csrfTokenLength {
+		token = c.random(csrfTokenLength)
+		c.http.Jar.SetCookies(u, []*http.Cookie{{
+			Name:  nosurf.CookieName,
+			Value: base64.StdEncoding.EncodeToString(token),
+			Path:  "/",
+		}})
+	}
+
+	pad := c.random(csrfTokenLength)
+	masked := make([]byte, 0, 2*csrfTokenLength)
+	masked = append(masked, pad...)
+	for i := range token {
+		masked = append(masked, token[i]^pad[i])
+	}
+	return base64.StdEncoding.EncodeToString(masked)
+}
+
+func (c *Client) random(n int) []byte {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		c.t.Fatalf("rkttest: %v", err)
+	}
+	return b
+}
+
+// session loads the server side session that belongs to the client's cookie
+func (c *Client) session() context.Context {
+	c.t.Helper()
+
+	u, _ := url.Parse(c.server.URL)
+
+	var token string
+	for _, cookie := range c.http.Jar.Cookies(u) {
+		if cookie.Name == c.app.Session.Cookie.Name {
+			token = cookie.Value
+		}
+	}
+
+	ctx, err := c.app.Session.Load(context.Background(), token)
+	if err != nil {
+		c.t.Fatalf("rkttest: loading session: %v", err)
+	}
+	return ctx
+}
+
+// SessionValue returns a value from the client's session without removing it
+func (c *Client) SessionValue(key string) any {
+	c.t.Helper()
+	return c.app.Session.Get(c.session(), key)
+}
+
+// AssertFlash fails the test unless the client's session holds the flash
+// message, which is left in place for the next page to show
+func (c *Client) AssertFlash(want string) {
+	c.t.Helper()
+
+	got, _ := c.SessionValue("flash").(string)
+	if got != want {
+		c.t.Fatalf("rkttest: expected flash %q, got %q", want, got)
+	}
+}
+
+// Bytes returns the response body
+func (r *Response) Bytes() []byte {
+	return r.body
+}
+
+// String returns the response body as a string
+func (r *Response) String() string {
+	return string(r.body)
+}
+
+// AssertStatus fails the test unless the response has the status code
+func (r *Response) AssertStatus(code int) *Response {
+	r.t.Helper()
+
+	if r.StatusCode != code {
+		r.t.Fatalf("rkttest: expected status %d, got %d: %s", code, r.StatusCode, r.body)
+	}
+	return r
+}
+
+// AssertRedirect fails the test unless the response redirects to location
+func (r *Response) AssertRedirect(location string) *Response {
+	r.t.Helper()
+
+	if r.StatusCode < 300 || r.StatusCode > 399 {
+		r.t.Fatalf("rkttest: expected a redirect to %s, got status %d", location, r.StatusCode)
+	}
+	if got := r.Header.Get("Location"); got != location {
+		r.t.Fatalf("rkttest: expected a redirect to %s, got %s", location, got)
+	}
+	return r
+}
+
+// DecodeJSON decodes the response body into v
+func (r *Response) DecodeJSON(v any) {
+	r.t.Helper()
+
+	if err := json.Unmarshal(r.body, v); err != nil {
+		r.t.Fatalf("rkttest: decoding json %q: %v", r.body, err)
+	}
+}
@@ -0,0 +1,64 @@
+package rkttest
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/m-goku/rkt/mailer"
+)
+
+// SentMail is a message captured by the Mailbox, with its rendered bodies
+type SentMail struct {
+	mailer.Message
+	HTML  string
+	Plain string
+}
+
+// Mailbox is a mailer.Transport that keeps messages instead of sending them
+type Mailbox struct {
+	t    testing.TB
+	mu   sync.Mutex
+	sent []SentMail
+}
+
+// Deliver implements mailer.Transport
+func (m *Mailbox) Deliver(msg mailer.Message, html, plain string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sent = append(m.sent, SentMail{Message: msg, HTML: html, Plain: plain})
+	return nil
+}
+
+// Sent returns every captured message, oldest first
+func (m *Mailbox) Sent() []SentMail {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]SentMail(nil), m.sent...)
+}
+
+// AssertSent fails the test unless a message was sent to the address, and
+// returns the last such message
+func (m *Mailbox) AssertSent(to string) SentMail {
+	m.t.Helper()
+
+	sent := m.Sent()
+	for i := len(sent) - 1; i >= 0; i-- {
+		if sent[i].To == to {
+			return sent[i]
+		}
+	}
+
+	m.t.Fatalf("rkttest: expected mail to %s, %d other messages were sent", to, len(sent))
+	return SentMail{}
+}
+
+// AssertNoneSent fails the test if any message was sent
+func (m *Mailbox) AssertNoneSent() {
+	m.t.Helper()
+
+	if sent := m.Sent(); len(sent) > 0 {
+		m.t.Fatalf("rkttest: expected no mail, %d messages were sent", len(sent))
+	}
+}
@@ -0,0 +1,189 @@
+/*
+Package rkttest builds fully wired rkt apps for handler tests. An app made by
+New needs no .env file, database or redis: it uses an in-memory cache, cookie
+sessions held in memory, and a mailer that captures messages instead of
+sending them. A database can be plugged in with WithDatabase.
+
+	app := rkttest.New(t)
+	app.Routes.Post("/contact", handlers.Contact)
+
+	c := app.Client()
+	c.PostForm("/contact", url.Values{"email": {"me@example.com"}}).AssertStatus(http.StatusSeeOther)
+	c.AssertFlash("Thanks, we'll be in touch")
+	app.Mailbox.AssertSent("me@example.com")
+*/
+package rkttest
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"sync"
+	"testing"
+
+	"github.com/m-goku/rkt"
+	"github.com/m-goku/rkt/render"
+)
+
+// App is an rkt app built for a single test
+type App struct {
+	*rkt.RKT
+	// Mailbox holds every message the app sent
+	Mailbox *Mailbox
+
+	t       testing.TB
+	mu      sync.Mutex
+	renders []Rendered
+}
+
+// Rendered is a page rendered by the app, with the template data it got
+type Rendered struct {
+	View string
+	Data *render.TemplateData
+}
+
+// Option changes how New builds the app
+type Option func(*options)
+
+type options struct {
+	rootPath string
+	config   []func(*rkt.Config)
+	before   []func(*rkt.RKT)
+	dbType   string
+	db       *sql.DB
+}
+
+// WithRootPath builds the app in the given folder, so that views and mail
+// templates of a real project are used. By default a temp folder is used.
+func WithRootPath(path string) Option {
+	return func(o *options) {
+		o.rootPath = path
+	}
+}
+
+// WithConfig changes the test configuration before the app is built
+func WithConfig(fn func(cfg *rkt.Config)) Option {
+	return func(o *options) {
+		o.config = append(o.config, fn)
+	}
+}
+
+// WithDatabase hands an open database to the app instead of letting it
+// connect, and sets the database type to dataType. Sessions can be stored
+// in it by setting the session type with WithConfig, once the sessions
+// table exists. The app closes the pool when the test ends.
+func WithDatabase(dataType string, db *sql.DB) Option {
+	return func(o *options) {
+		o.dbType = dataType
+		o.db = db
+	}
+}
+
+// Before runs fn on the app before it is built, which is where OnBoot hooks
+// have to be registered
+func Before(fn func(r *rkt.RKT)) Option {
+	return func(o *options) {
+		o.before = append(o.before, fn)
+	}
+}
+
+// New builds an app for the test and shuts it down when the test ends
+func New(t testing.TB, opts ...Option) *App {
+	t.Helper()
+
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.rootPath == "" {
+		o.rootPath = t.TempDir()
+	}
+
+	cfg := rkt.DefaultConfig()
+	cfg.AppName = "rkttest"
+	cfg.Key = randomKey(t)
+	cfg.Server.Name = "localhost"
+	cfg.Server.Secure = false
+	cfg.Cache.Type = "memory"
+	cfg.Session.Type = "cookie"
+	cfg.Cookie.Name = "rkttest_session"
+	cfg.Cookie.Secure = false
+	cfg.Renderer.Engine = "jet"
+	cfg.Log.Level = "error"
+	if o.db != nil {
+		cfg.Database.Type = o.dbType
+	}
+	for _, fn := range o.config {
+		fn(&cfg)
+	}
+
+	app := &App{
+		RKT:     &rkt.RKT{},
+		Mailbox: &Mailbox{t: t},
+		t:       t,
+	}
+	if o.db != nil {
+		app.DB = rkt.Database{DataType: o.dbType, Pool: o.db}
+	}
+	for _, fn := range o.before {
+		fn(app.RKT)
+	}
+
+	err := app.NewWithConfig(o.rootPath, cfg)
+	if err != nil {
+		t.Fatalf("rkttest: building app: %v", err)
+	}
+
+	app.Mail.Transport = app.Mailbox
+	app.Render.OnRender = app.record
+
+	t.Cleanup(func() {
+		if err := app.Shutdown(context.Background()); err != nil {
+			t.Errorf("rkttest: shutting down app: %v", err)
+		}
+	})
+
+	return app
+}
+
+func (a *App) record(view string, data *render.TemplateData) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.renders = append(a.renders, Rendered{View: view, Data: data})
+}
+
+// Renders returns every page rendered so far, oldest first
+func (a *App) Renders() []Rendered {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return append([]Rendered(nil), a.renders...)
+}
+
+// AssertRendered fails the test unless view is the last page rendered, and
+// returns its template data
+func (a *App) AssertRendered(view string) *render.TemplateData {
+	a.t.Helper()
+
+	renders := a.Renders()
+	if len(renders) == 0 {
+		a.t.Fatalf("rkttest: expected %q to be rendered, but nothing was", view)
+	}
+
+	last := renders[len(renders)-1]
+	if last.View != view {
+		a.t.Fatalf("rkttest: expected %q to be rendered, got %q", view, last.View)
+	}
+	return last.Data
+}
+
+// randomKey returns a 32 character encryption key
+func randomKey(t testing.TB) string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatalf("rkttest: %v", err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,139 @@
+package rkttest
+
+import (
+	"database/sql"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/m-goku/rkt"
+	"github.com/m-goku/rkt/mailer"
+	"github.com/m-goku/rkt/render"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestApp(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "views", "home.jet"), `<p>{{ .StringMap["name"] }}</p>`)
+	writeFile(t, filepath.Join(root, "mail", "welcome.html.tmpl"), `{{define "body"}}<p>Hello {{.}}</p>{{end}}`)
+	writeFile(t, filepath.Join(root, "mail", "welcome.plain.tmpl"), `{{define "body"}}Hello {{.}}{{end}}`)
+
+	app := New(t, WithRootPath(root))
+
+	app.Routes.Get("/", func(w http.ResponseWriter, r *http.Request) {
+		td := &render.TemplateData{StringMap: map[string]string{"name": "rkt"}}
+		if err := app.Render.Page(w, r, "home", nil, td); err != nil {
+			app.Error500(w, r)
+		}
+	})
+	app.Routes.Post("/signup", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		email := r.Form.Get("email")
+		if err := app.Mail.Send(mailer.Message{To: email, Template: "welcome", Data: email}); err != nil {
+			app.Error500(w, r)
+			return
+		}
+		app.Session.Put(r.Context(), "flash", "Welcome aboard")
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+	})
+	app.Routes.Post("/echo", func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		if err := app.ReadJSON(w, r, &payload); err != nil {
+			app.Error500(w, r)
+			return
+		}
+		_ = app.WriteJSON(w, http.StatusOK, payload)
+	})
+
+	c := app.Client()
+
+	c.Get("/").AssertStatus(http.StatusOK)
+	td := app.AssertRendered("home")
+	if td.StringMap["name"] != "rkt" {
+		t.Errorf("unexpected template data %v", td.StringMap)
+	}
+
+	c.PostForm("/signup", url.Values{"email": {"me@example.com"}}).AssertRedirect("/")
+	c.AssertFlash("Welcome aboard")
+
+	sent := app.Mailbox.AssertSent("me@example.com")
+	if sent.Plain != "Hello me@example.com" {
+		t.Errorf("unexpected plain body %q", sent.Plain)
+	}
+
+	var out map[string]string
+	c.PostJSON("/echo", map[string]string{"hello": "world"}).AssertStatus(http.StatusOK).DecodeJSON(&out)
+	if out["hello"] != "world" {
+		t.Errorf("unexpected json %v", out)
+	}
+}
+
+func TestClient_RejectsMissingCSRFToken(t *testing.T) {
+	app := New(t)
+	app.Routes.Post("/form", func(w http.ResponseWriter, r *http.Request) {})
+
+	c := app.Client()
+	resp, err := c.http.PostForm(c.URL("/form"), url.Values{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected NoSurf to reject the request, got %d", resp.StatusCode)
+	}
+}
+
+func TestWithDatabase_SQLiteSessions(t *testing.T) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "test.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema, err := os.ReadFile("../cmd/cli/templates/migrations/sqlite_session.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(string(schema)); err != nil {
+		t.Fatal(err)
+	}
+
+	app := New(t,
+		WithDatabase("sqlite", db),
+		WithConfig(func(cfg *rkt.Config) { cfg.Session.Type = "sqlite" }),
+	)
+	if app.DB.Pool != db {
+		t.Fatal("expected the app to use the database it was handed")
+	}
+
+	app.Routes.Get("/put", func(w http.ResponseWriter, r *http.Request) {
+		app.Session.Put(r.Context(), "name", "rkt")
+	})
+	app.Routes.Get("/get", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(app.Session.GetString(r.Context(), "name")))
+	})
+
+	c := app.Client()
+	c.Get("/put").AssertStatus(http.StatusOK)
+	if body := c.Get("/get").AssertStatus(http.StatusOK).String(); body != "rkt" {
+		t.Errorf("expected the session value back, got %q", body)
+	}
+
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sessions").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("expected the session to be stored in the database, found %d rows", n)
+	}
+}
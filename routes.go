@@ -7,7 +7,8 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 )
 
-func (r *RKT) routes() http.Handler {
+// routes builds the mux that apps add their routes and middleware to
+func (r *RKT) routes() *chi.Mux {
 	mux := chi.NewRouter()
 	mux.Use(middleware.RequestID)
 	mux.Use(middleware.RealIP)
@@ -16,8 +17,40 @@ func (r *RKT) routes() http.Handler {
 		mux.Use(middleware.Logger)
 	}
 	mux.Use(middleware.Recoverer)
+	mux.Use(r.HSTS)
 	mux.Use(r.SessionLoad)
+	mux.Use(r.LogRequest)
 	mux.Use(r.NoSurf)
 
 	return mux
 }
+
+// endpoints puts the probes and metrics in front of the app's routes. They
+// live on a mux of their own so that they skip sessions and csrf cookies,
+// and so that apps can still add middleware to Routes once they're set up.
+// Every other request falls through to Routes.
+func (r *RKT) endpoints(app *chi.Mux) http.Handler {
+	mux := chi.NewRouter()
+
+	if r.config.Health.Enabled {
+		mux.Get(r.config.Health.LivenessPath, r.Healthz)
+		mux.Head(r.config.Health.LivenessPath, r.Healthz)
+		mux.Get(r.config.Health.ReadinessPath, r.Readyz)
+		mux.Head(r.config.Health.ReadinessPath, r.Readyz)
+	}
+
+	if r.metrics != nil {
+		mux.Handle(r.config.Metrics.Path, r.metrics.handler)
+		mux.NotFound(r.instrument(app).ServeHTTP)
+	} else {
+		mux.NotFound(app.ServeHTTP)
+	}
+
+	return mux
+}
+
+// Handler returns what the server serves: the probe and metrics endpoints,
+// then Routes. Use it rather than Routes to serve the app some other way.
+func (r *RKT) Handler() http.Handler {
+	return r.handler
+}
@@ -0,0 +1,384 @@
+package rkt
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// schemaHeader starts every schema dump. It carries no timestamp, so that
+// the file only changes when the schema does.
+const schemaHeader = `-- Schema of the database, generated by rkt db schema. Do not edit this
+-- file; run rkt db schema, or set DATABASE_SCHEMA_DUMP, to regenerate it.
+`
+
+// pgSchema is everything a schema dump holds, in the order it is written
+type pgSchema struct {
+	Extensions  []string
+	Enums       []pgEnum
+	Functions   []string
+	Sequences   []pgSequence
+	Tables      []pgTable
+	ForeignKeys []pgConstraint
+	Indexes     []string
+	Views       []pgView
+	Triggers    []string
+}
+
+type pgEnum struct {
+	Name   string
+	Labels string // quoted and comma separated
+}
+
+type pgSequence struct {
+	Name      string
+	Type      string
+	Start     int64
+	Increment int64
+	OwnedBy   string // table.column, for the sequences of serial columns
+}
+
+type pgTable struct {
+	Name        string
+	Columns     []pgColumn
+	Constraints []pgConstraint
+}
+
+type pgColumn struct {
+	Name      string
+	Type      string
+	NotNull   bool
+	Default   string
+	Identity  string // a for always, d for by default
+	Generated string // s for stored generated columns
+}
+
+type pgConstraint struct {
+	Table string
+	Name  string
+	Def   string
+}
+
+type pgView struct {
+	Name         string
+	Materialized bool
+	Def          string
+}
+
+/*
+DumpSchema writes the schema of a postgres database to w as sql: the
+extensions, enum types, functions, sequences, tables with their columns and
+constraints, foreign keys, indexes, views and triggers, each sorted by name.
+It reads pg_catalog directly, so pg_dump doesn't have to be installed, and
+covers every schema except the system ones.
+*/
+func (d Database) DumpSchema(ctx context.Context, w io.Writer) error {
+	if sqlFamily(d.DataType) != "postgres" {
+		return fmt.Errorf("rkt: schema dumps need a postgres database, not %q", d.DataType)
+	}
+	if d.Pool == nil {
+		return ErrNoDatabase
+	}
+
+	// one snapshot, so that a migration running meanwhile can't tear the dump
+	tx, err := d.Pool.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	schema, err := readPgSchema(ctx, tx)
+	if err != nil {
+		return err
+	}
+	return schema.write(w)
+}
+
+// pgUserObjects limits a catalog query to the schemas an app creates, and
+// leaves out objects that belong to an extension. %s is the oid of the
+// object.
+const pgUserObjects = `n.nspname !~ '^pg_' AND n.nspname <> 'information_schema'
+	AND NOT EXISTS (SELECT 1 FROM pg_depend ext WHERE ext.objid = %s AND ext.deptype = 'e')`
+
+// pgName is the sql for the name of an object, with the schema left out for
+// public. %[1]s is the pg_namespace alias and %[2]s the name column.
+const pgName = `CASE WHEN %[1]s.nspname = 'public' THEN quote_ident(%[2]s)
+	ELSE quote_ident(%[1]s.nspname) || '.' || quote_ident(%[2]s) END`
+
+func readPgSchema(ctx context.Context, tx *sql.Tx) (*pgSchema, error) {
+	s := &pgSchema{}
+	tables := make(map[string]int) // index into s.Tables by name
+
+	// the queries run in order: the columns query creates the tables that
+	// the constraints query fills in
+	queries := []struct {
+		query string
+		scan  func(rows *sql.Rows) error
+	}{
+		{
+			query: `SELECT quote_ident(extname) FROM pg_extension WHERE extname <> 'plpgsql' ORDER BY extname`,
+			scan: func(rows *sql.Rows) error {
+				var name string
+				err := rows.Scan(&name)
+				s.Extensions = append(s.Extensions, name)
+				return err
+			},
+		},
+		{
+			query: fmt.Sprintf(`SELECT %s, string_agg(quote_literal(e.enumlabel), ', ' ORDER BY e.enumsortorder)
+				FROM pg_type t
+				JOIN pg_namespace n ON n.oid = t.typnamespace
+				JOIN pg_enum e ON e.enumtypid = t.oid
+				WHERE %s
+				GROUP BY n.nspname, t.typname
+				ORDER BY 1`, fmt.Sprintf(pgName, "n", "t.typname"), fmt.Sprintf(pgUserObjects, "t.oid")),
+			scan: func(rows *sql.Rows) error {
+				var e pgEnum
+				err := rows.Scan(&e.Name, &e.Labels)
+				s.Enums = append(s.Enums, e)
+				return err
+			},
+		},
+		{
+			query: fmt.Sprintf(`SELECT pg_get_functiondef(p.oid)
+				FROM pg_proc p
+				JOIN pg_namespace n ON n.oid = p.pronamespace
+				WHERE p.prokind IN ('f', 'p') AND %s
+				ORDER BY n.nspname, p.proname, pg_get_function_identity_arguments(p.oid)`, fmt.Sprintf(pgUserObjects, "p.oid")),
+			scan: func(rows *sql.Rows) error {
+				var def string
+				err := rows.Scan(&def)
+				s.Functions = append(s.Functions, def)
+				return err
+			},
+		},
+		{
+			// identity columns create their sequences themselves
+			query: fmt.Sprintf(`SELECT %s, format_type(sq.seqtypid, NULL), sq.seqstart, sq.seqincrement,
+					COALESCE(%s || '.' || quote_ident(a.attname), '')
+				FROM pg_class c
+				JOIN pg_namespace n ON n.oid = c.relnamespace
+				JOIN pg_sequence sq ON sq.seqrelid = c.oid
+				LEFT JOIN pg_depend d ON d.objid = c.oid AND d.classid = 'pg_class'::regclass AND d.deptype = 'a' AND d.refobjsubid > 0
+				LEFT JOIN pg_class t ON t.oid = d.refobjid
+				LEFT JOIN pg_namespace tn ON tn.oid = t.relnamespace
+				LEFT JOIN pg_attribute a ON a.attrelid = d.refobjid AND a.attnum = d.refobjsubid
+				WHERE c.relkind = 'S' AND %s
+					AND NOT EXISTS (SELECT 1 FROM pg_depend i WHERE i.objid = c.oid AND i.deptype = 'i')
+				ORDER BY 1`, fmt.Sprintf(pgName, "n", "c.relname"), fmt.Sprintf(pgName, "tn", "t.relname"), fmt.Sprintf(pgUserObjects, "c.oid")),
+			scan: func(rows *sql.Rows) error {
+				var seq pgSequence
+				err := rows.Scan(&seq.Name, &seq.Type, &seq.Start, &seq.Increment, &seq.OwnedBy)
+				s.Sequences = append(s.Sequences, seq)
+				return err
+			},
+		},
+		{
+			query: fmt.Sprintf(`SELECT %s, quote_ident(a.attname), format_type(a.atttypid, a.atttypmod), a.attnotnull,
+					COALESCE(pg_get_expr(ad.adbin, ad.adrelid), ''), a.attidentity::text, a.attgenerated::text
+				FROM pg_class c
+				JOIN pg_namespace n ON n.oid = c.relnamespace
+				JOIN pg_attribute a ON a.attrelid = c.oid AND a.attnum > 0 AND NOT a.attisdropped
+				LEFT JOIN pg_attrdef ad ON ad.adrelid = a.attrelid AND ad.adnum = a.attnum
+				WHERE c.relkind IN ('r', 'p') AND NOT c.relispartition AND %s
+				ORDER BY 1, a.attnum`, fmt.Sprintf(pgName, "n", "c.relname"), fmt.Sprintf(pgUserObjects, "c.oid")),
+			scan: func(rows *sql.Rows) error {
+				var table string
+				var col pgColumn
+				if err := rows.Scan(&table, &col.Name, &col.Type, &col.NotNull, &col.Default, &col.Identity, &col.Generated); err != nil {
+					return err
+				}
+
+				i, ok := tables[table]
+				if !ok {
+					i = len(s.Tables)
+					tables[table] = i
+					s.Tables = append(s.Tables, pgTable{Name: table})
+				}
+				s.Tables[i].Columns = append(s.Tables[i].Columns, col)
+				return nil
+			},
+		},
+		{
+			// foreign keys are added once every table exists
+			query: fmt.Sprintf(`SELECT %s, quote_ident(con.conname), con.contype::text, pg_get_constraintdef(con.oid)
+				FROM pg_constraint con
+				JOIN pg_class c ON c.oid = con.conrelid
+				JOIN pg_namespace n ON n.oid = c.relnamespace
+				WHERE c.relkind IN ('r', 'p') AND NOT c.relispartition AND con.contype IN ('p', 'u', 'x', 'c', 'f') AND %s
+				ORDER BY 1, position(con.contype::text in 'puxcf'), 2`, fmt.Sprintf(pgName, "n", "c.relname"), fmt.Sprintf(pgUserObjects, "c.oid")),
+			scan: func(rows *sql.Rows) error {
+				var con pgConstraint
+				var kind string
+				if err := rows.Scan(&con.Table, &con.Name, &kind, &con.Def); err != nil {
+					return err
+				}
+
+				if kind == "f" {
+					s.ForeignKeys = append(s.ForeignKeys, con)
+				} else if i, ok := tables[con.Table]; ok {
+					s.Tables[i].Constraints = append(s.Tables[i].Constraints, con)
+				}
+				return nil
+			},
+		},
+		{
+			// indexes that back a constraint come with the constraint
+			query: fmt.Sprintf(`SELECT pg_get_indexdef(i.indexrelid)
+				FROM pg_index i
+				JOIN pg_class c ON c.oid = i.indrelid
+				JOIN pg_class ic ON ic.oid = i.indexrelid
+				JOIN pg_namespace n ON n.oid = c.relnamespace
+				WHERE c.relkind IN ('r', 'p', 'm') AND NOT c.relispartition AND %s
+					AND NOT EXISTS (SELECT 1 FROM pg_constraint con
+						WHERE con.conindid = i.indexrelid AND con.conrelid = i.indrelid AND con.contype IN ('p', 'u', 'x'))
+				ORDER BY %s, ic.relname`, fmt.Sprintf(pgUserObjects, "c.oid"), fmt.Sprintf(pgName, "n", "c.relname")),
+			scan: func(rows *sql.Rows) error {
+				var def string
+				err := rows.Scan(&def)
+				s.Indexes = append(s.Indexes, def)
+				return err
+			},
+		},
+		{
+			query: fmt.Sprintf(`SELECT %s, c.relkind = 'm', pg_get_viewdef(c.oid, true)
+				FROM pg_class c
+				JOIN pg_namespace n ON n.oid = c.relnamespace
+				WHERE c.relkind IN ('v', 'm') AND %s
+				ORDER BY 1`, fmt.Sprintf(pgName, "n", "c.relname"), fmt.Sprintf(pgUserObjects, "c.oid")),
+			scan: func(rows *sql.Rows) error {
+				var v pgView
+				err := rows.Scan(&v.Name, &v.Materialized, &v.Def)
+				s.Views = append(s.Views, v)
+				return err
+			},
+		},
+		{
+			query: fmt.Sprintf(`SELECT pg_get_triggerdef(tg.oid, true)
+				FROM pg_trigger tg
+				JOIN pg_class c ON c.oid = tg.tgrelid
+				JOIN pg_namespace n ON n.oid = c.relnamespace
+				WHERE NOT tg.tgisinternal AND NOT c.relispartition AND %s
+				ORDER BY %s, tg.tgname`, fmt.Sprintf(pgUserObjects, "c.oid"), fmt.Sprintf(pgName, "n", "c.relname")),
+			scan: func(rows *sql.Rows) error {
+				var def string
+				err := rows.Scan(&def)
+				s.Triggers = append(s.Triggers, def)
+				return err
+			},
+		},
+	}
+
+	for _, q := range queries {
+		if err := scanRows(ctx, tx, q.query, q.scan); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// scanRows runs query and calls scan for each row
+func scanRows(ctx context.Context, tx *sql.Tx, query string, scan func(rows *sql.Rows) error) error {
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// write prints the schema as sql, in an order that can be replayed
+func (s *pgSchema) write(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	b.WriteString(schemaHeader)
+
+	for _, ext := range s.Extensions {
+		fmt.Fprintf(b, "\nCREATE EXTENSION IF NOT EXISTS %s;\n", ext)
+	}
+
+	for _, e := range s.Enums {
+		fmt.Fprintf(b, "\nCREATE TYPE %s AS ENUM (%s);\n", e.Name, e.Labels)
+	}
+
+	for _, fn := range s.Functions {
+		fmt.Fprintf(b, "\n%s;\n", strings.TrimRight(fn, "\n"))
+	}
+
+	for _, seq := range s.Sequences {
+		fmt.Fprintf(b, "\nCREATE SEQUENCE %s AS %s START WITH %d INCREMENT BY %d;\n", seq.Name, seq.Type, seq.Start, seq.Increment)
+	}
+
+	for _, t := range s.Tables {
+		var lines []string
+		for _, col := range t.Columns {
+			lines = append(lines, "    "+col.definition())
+		}
+		for _, con := range t.Constraints {
+			lines = append(lines, fmt.Sprintf("    CONSTRAINT %s %s", con.Name, con.Def))
+		}
+		fmt.Fprintf(b, "\nCREATE TABLE %s (\n%s\n);\n", t.Name, strings.Join(lines, ",\n"))
+	}
+
+	for _, seq := range s.Sequences {
+		if seq.OwnedBy != "" {
+			fmt.Fprintf(b, "\nALTER SEQUENCE %s OWNED BY %s;\n", seq.Name, seq.OwnedBy)
+		}
+	}
+
+	for _, fk := range s.ForeignKeys {
+		fmt.Fprintf(b, "\nALTER TABLE ONLY %s\n    ADD CONSTRAINT %s %s;\n", fk.Table, fk.Name, fk.Def)
+	}
+
+	for _, idx := range s.Indexes {
+		fmt.Fprintf(b, "\n%s;\n", idx)
+	}
+
+	for _, v := range s.Views {
+		kind := "VIEW"
+		if v.Materialized {
+			kind = "MATERIALIZED VIEW"
+		}
+		fmt.Fprintf(b, "\nCREATE %s %s AS\n%s\n", kind, v.Name, strings.TrimRight(v.Def, "\n"))
+	}
+
+	for _, tg := range s.Triggers {
+		fmt.Fprintf(b, "\n%s;\n", tg)
+	}
+
+	return b.Flush()
+}
+
+// definition is the column as written in CREATE TABLE
+func (c pgColumn) definition() string {
+	def := c.Name + " " + c.Type
+
+	switch {
+	case c.Generated == "s":
+		def += " GENERATED ALWAYS AS (" + c.Default + ") STORED"
+	case c.Default != "":
+		def += " DEFAULT " + c.Default
+	}
+
+	if c.NotNull {
+		def += " NOT NULL"
+	}
+
+	switch c.Identity {
+	case "a":
+		def += " GENERATED ALWAYS AS IDENTITY"
+	case "d":
+		def += " GENERATED BY DEFAULT AS IDENTITY"
+	}
+
+	return def
+}
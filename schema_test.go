@@ -0,0 +1,197 @@
+package rkt
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestPgSchema_Write(t *testing.T) {
+	s := &pgSchema{
+		Functions: []string{"CREATE OR REPLACE FUNCTION public.trigger_set_timestamp()\n RETURNS trigger\n LANGUAGE plpgsql\nAS $function$\nBEGIN\n  NEW.updated_at = NOW();\nRETURN NEW;\nEND;\n$function$\n"},
+		Sequences: []pgSequence{{Name: "users_id_seq", Type: "integer", Start: 1, Increment: 1, OwnedBy: "users.id"}},
+		Tables: []pgTable{
+			{
+				Name: "tokens",
+				Columns: []pgColumn{
+					{Name: "id", Type: "bigint", NotNull: true, Identity: "a"},
+					{Name: "user_id", Type: "integer", NotNull: true},
+				},
+				Constraints: []pgConstraint{{Table: "tokens", Name: "tokens_pkey", Def: "PRIMARY KEY (id)"}},
+			},
+			{
+				Name: "users",
+				Columns: []pgColumn{
+					{Name: "id", Type: "integer", NotNull: true, Default: "nextval('users_id_seq'::regclass)"},
+					{Name: "email", Type: "character varying(255)"},
+					{Name: "domain", Type: "text", Default: "split_part((email)::text, '@'::text, 2)", Generated: "s"},
+				},
+				Constraints: []pgConstraint{{Table: "users", Name: "users_pkey", Def: "PRIMARY KEY (id)"}},
+			},
+		},
+		ForeignKeys: []pgConstraint{{Table: "tokens", Name: "tokens_user_id_fkey", Def: "FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE"}},
+		Indexes:     []string{"CREATE UNIQUE INDEX users_email_idx ON public.users USING btree (email)"},
+		Triggers:    []string{"CREATE TRIGGER set_timestamp BEFORE UPDATE ON users FOR EACH ROW EXECUTE FUNCTION trigger_set_timestamp()"},
+	}
+
+	var b strings.Builder
+	if err := s.write(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	want := schemaHeader + `
+CREATE OR REPLACE FUNCTION public.trigger_set_timestamp()
+ RETURNS trigger
+ LANGUAGE plpgsql
+AS $function$
+BEGIN
+  NEW.updated_at = NOW();
+RETURN NEW;
+END;
+$function$;
+
+CREATE SEQUENCE users_id_seq AS integer START WITH 1 INCREMENT BY 1;
+
+CREATE TABLE tokens (
+    id bigint NOT NULL GENERATED ALWAYS AS IDENTITY,
+    user_id integer NOT NULL,
+    CONSTRAINT tokens_pkey PRIMARY KEY (id)
+);
+
+CREATE TABLE users (
+    id integer DEFAULT nextval('users_id_seq'::regclass) NOT NULL,
+    email character varying(255),
+    domain text GENERATED ALWAYS AS (split_part((email)::text, '@'::text, 2)) STORED,
+    CONSTRAINT users_pkey PRIMARY KEY (id)
+);
+
+ALTER SEQUENCE users_id_seq OWNED BY users.id;
+
+ALTER TABLE ONLY tokens
+    ADD CONSTRAINT tokens_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+CREATE UNIQUE INDEX users_email_idx ON public.users USING btree (email);
+
+CREATE TRIGGER set_timestamp BEFORE UPDATE ON users FOR EACH ROW EXECUTE FUNCTION trigger_set_timestamp();
+`
+	if got := b.String(); got != want {
+		t.Errorf("unexpected schema:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDatabase_DumpSchemaNeedsPostgres(t *testing.T) {
+	d := newItemsDB(t)
+
+	if err := d.DumpSchema(context.Background(), io.Discard); err == nil || !strings.Contains(err.Error(), "postgres") {
+		t.Errorf("expected sqlite to be rejected, got %v", err)
+	}
+}
+
+// TestDatabase_DumpSchemaPostgres runs the catalog queries against a real
+// server. It needs RKT_TEST_POSTGRES_DSN to point at a scratch database.
+func TestDatabase_DumpSchemaPostgres(t *testing.T) {
+	dsn := os.Getenv("RKT_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("RKT_TEST_POSTGRES_DSN is not set")
+	}
+
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	drop := `DROP SCHEMA IF EXISTS rkt_dump_test CASCADE`
+	if _, err := db.ExecContext(ctx, drop); err != nil {
+		t.Fatal(err)
+	}
+	defer db.ExecContext(ctx, drop)
+
+	_, err = db.ExecContext(ctx, `
+CREATE SCHEMA rkt_dump_test;
+
+CREATE FUNCTION rkt_dump_test.trigger_set_timestamp() RETURNS trigger AS $$
+BEGIN
+  NEW.updated_at = NOW();
+RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TABLE rkt_dump_test.users (
+    id serial PRIMARY KEY,
+    email character varying(255) NOT NULL UNIQUE,
+    active boolean NOT NULL DEFAULT true,
+    updated_at timestamp without time zone
+);
+
+CREATE TABLE rkt_dump_test.tokens (
+    id bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    user_id integer NOT NULL REFERENCES rkt_dump_test.users(id) ON DELETE CASCADE,
+    expiry timestamp without time zone NOT NULL,
+    CONSTRAINT tokens_expiry_check CHECK (expiry > '2000-01-01')
+);
+
+CREATE INDEX tokens_expiry_idx ON rkt_dump_test.tokens (expiry);
+
+CREATE VIEW rkt_dump_test.active_users AS SELECT id, email FROM rkt_dump_test.users WHERE active;
+
+CREATE TRIGGER set_timestamp BEFORE UPDATE ON rkt_dump_test.users
+    FOR EACH ROW EXECUTE PROCEDURE rkt_dump_test.trigger_set_timestamp();
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b strings.Builder
+	if err := (Database{DataType: "postgres", Pool: db}).DumpSchema(ctx, &b); err != nil {
+		t.Fatal(err)
+	}
+	dump := b.String()
+
+	// each statement, in the order it has to be replayed
+	want := []string{
+		"CREATE OR REPLACE FUNCTION rkt_dump_test.trigger_set_timestamp()",
+		"CREATE SEQUENCE rkt_dump_test.users_id_seq AS integer START WITH 1 INCREMENT BY 1;",
+		"CREATE TABLE rkt_dump_test.tokens (\n" +
+			"    id bigint NOT NULL GENERATED ALWAYS AS IDENTITY,\n" +
+			"    user_id integer NOT NULL,\n" +
+			"    expiry timestamp without time zone NOT NULL,\n" +
+			"    CONSTRAINT tokens_pkey PRIMARY KEY (id),\n" +
+			"    CONSTRAINT tokens_expiry_check CHECK",
+		"CREATE TABLE rkt_dump_test.users (\n" +
+			"    id integer DEFAULT nextval('rkt_dump_test.users_id_seq'::regclass) NOT NULL,\n" +
+			"    email character varying(255) NOT NULL,\n" +
+			"    active boolean DEFAULT true NOT NULL,\n" +
+			"    updated_at timestamp without time zone,\n" +
+			"    CONSTRAINT users_pkey PRIMARY KEY (id),\n" +
+			"    CONSTRAINT users_email_key UNIQUE (email)\n" +
+			");",
+		"ALTER SEQUENCE rkt_dump_test.users_id_seq OWNED BY rkt_dump_test.users.id;",
+		"ALTER TABLE ONLY rkt_dump_test.tokens\n    ADD CONSTRAINT tokens_user_id_fkey FOREIGN KEY (user_id) REFERENCES rkt_dump_test.users(id) ON DELETE CASCADE;",
+		"CREATE INDEX tokens_expiry_idx ON rkt_dump_test.tokens USING btree (expiry);",
+		"CREATE VIEW rkt_dump_test.active_users AS\n",
+		"CREATE TRIGGER set_timestamp BEFORE UPDATE ON rkt_dump_test.users FOR EACH ROW EXECUTE",
+	}
+	last := 0
+	for _, stmt := range want {
+		i := strings.Index(dump, stmt)
+		if i < 0 {
+			t.Fatalf("expected the dump to contain %q, got:\n%s", stmt, dump)
+		}
+		if i < last {
+			t.Errorf("expected %q later in the dump", stmt)
+		}
+		last = i
+	}
+
+	// the identity column's sequence and the constraint indexes are implied
+	for _, unwanted := range []string{"tokens_id_seq", "CREATE UNIQUE INDEX users_email_key", "CREATE UNIQUE INDEX users_pkey"} {
+		if strings.Contains(dump, unwanted) {
+			t.Errorf("expected %q to be left out of the dump", unwanted)
+		}
+	}
+}
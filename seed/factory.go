@@ -0,0 +1,133 @@
+package seed
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/m-goku/rkt"
+)
+
+// User is a row of the users table created by rkt make auth, or a document
+// of the users collection on mongodb
+type User struct {
+	ID        int       `db:"id,omitempty" bson:"-"`
+	FirstName string    `db:"first_name" bson:"first_name"`
+	LastName  string    `db:"last_name" bson:"last_name"`
+	Email     string    `db:"email" bson:"email"`
+	Active    int       `db:"user_active" bson:"user_active"`
+	Password  string    `db:"password" bson:"password"`
+	CreatedAt time.Time `db:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" bson:"updated_at"`
+}
+
+// Factory makes fake records of T and stores them in Table, which is a
+// table on sql databases and a collection on mongodb. On sql databases the
+// db struct tags name the columns, and fields tagged omitempty are left out
+// when they hold their zero value, so that ids are generated.
+type Factory[T any] struct {
+	Table string
+	Make  func(f *Faker) (T, error)
+}
+
+// Users is the factory for the auth scaffold's users. Every user is active
+// and has DefaultPassword as password.
+var Users = Factory[User]{
+	Table: "users",
+	Make: func(f *Faker) (User, error) {
+		hash, err := f.PasswordHash()
+		if err != nil {
+			return User{}, err
+		}
+
+		first, last := f.FirstName(), f.LastName()
+		now := time.Now()
+		return User{
+			FirstName: first,
+			LastName:  last,
+			Email:     f.Email(first, last),
+			Active:    1,
+			Password:  hash,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}, nil
+	},
+}
+
+// MakeMany returns n records without storing them
+func (fac Factory[T]) MakeMany(f *Faker, n int) ([]T, error) {
+	records := make([]T, 0, n)
+	for i := 0; i < n; i++ {
+		record, err := fac.Make(f)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// Create makes n records and inserts them into the app's database, in one
+// transaction on sql databases
+func (fac Factory[T]) Create(ctx context.Context, app *rkt.RKT, f *Faker, n int) ([]T, error) {
+	records, err := fac.MakeMany(f, n)
+	if err != nil {
+		return nil, err
+	}
+
+	if app.DB.Conn != nil {
+		docs := make([]interface{}, len(records))
+		for i := range records {
+			docs[i] = records[i]
+		}
+		_, err := app.MongoDatabase().Collection(fac.Table).InsertMany(ctx, docs)
+		return records, err
+	}
+
+	db := app.DB
+	err = db.WithTx(ctx, nil, func(tx *sql.Tx) error {
+		for _, record := range records {
+			query, args := insertQuery(db.DataType, fac.Table, record)
+			if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+				return fmt.Errorf("seed: inserting into %s: %w", fac.Table, err)
+			}
+		}
+		return nil
+	})
+
+	return records, err
+}
+
+// insertQuery builds an insert statement for record from its db tags
+func insertQuery(dbType, table string, record any) (string, []any) {
+	v := reflect.Indirect(reflect.ValueOf(record))
+	t := v.Type()
+
+	var columns, placeholders []string
+	var args []any
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("db")
+		if tag == "" || tag == "-" || !t.Field(i).IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		if opts == "omitempty" && v.Field(i).IsZero() {
+			continue
+		}
+
+		columns = append(columns, name)
+		args = append(args, v.Field(i).Interface())
+		if dbType == "postgres" || dbType == "postgresql" || dbType == "pgx" {
+			placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
+		} else {
+			placeholders = append(placeholders, "?")
+		}
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+	return query, args
+}
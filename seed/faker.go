@@ -0,0 +1,153 @@
+package seed
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// DefaultPassword is the plain text password of every fake user
+const DefaultPassword = "password"
+
+// passwordCost matches the bcrypt cost used by the auth scaffold
+const passwordCost = 12
+
+var firstNames = []string{
+	"Ada", "Alan", "Amara", "Bea", "Carlos", "Chen", "Dara", "Elena", "Femi", "Grace",
+	"Hana", "Ivan", "Jamal", "Kofi", "Lena", "Mei", "Nia", "Omar", "Priya", "Quinn",
+	"Rosa", "Sami", "Tariq", "Uma", "Vera", "Wei", "Yara", "Zane",
+}
+
+var lastNames = []string{
+	"Adams", "Banda", "Costa", "Diaz", "Eze", "Fischer", "Garcia", "Hughes", "Ito", "Jensen",
+	"Kim", "Lopez", "Mensah", "Nakamura", "Okafor", "Patel", "Rossi", "Silva", "Tanaka", "Usman",
+	"Vargas", "Walker", "Xu", "Yilmaz", "Zhang",
+}
+
+var words = []string{
+	"alpha", "bright", "cedar", "delta", "ember", "fable", "garden", "harbor", "island", "jasper",
+	"kettle", "lantern", "meadow", "nectar", "orbit", "pepper", "quartz", "river", "summit", "timber",
+	"umber", "velvet", "willow", "yonder", "zephyr",
+}
+
+// Faker makes up names, emails, words and numbers. Two fakers made with the
+// same seed produce the same values, which keeps test data reproducible.
+// Email addresses are the exception: they carry a tag picked per faker, so
+// seeding the same database twice doesn't collide on them.
+type Faker struct {
+	rand   *rand.Rand
+	mu     sync.Mutex
+	emails int
+	tag    string
+	hash   string
+}
+
+// NewFaker returns a faker. A zero seed picks one from the clock.
+func NewFaker(seed int64) *Faker {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &Faker{
+		rand: rand.New(rand.NewSource(seed)),
+		tag:  fmt.Sprintf("%08x", rand.Uint32()),
+	}
+}
+
+// Intn returns a number in [0, n)
+func (f *Faker) Intn(n int) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rand.Intn(n)
+}
+
+// IntBetween returns a number in [min, max]
+func (f *Faker) IntBetween(min, max int) int {
+	return min + f.Intn(max-min+1)
+}
+
+// Bool returns true or false
+func (f *Faker) Bool() bool {
+	return f.Intn(2) == 1
+}
+
+// Pick returns one of options
+func (f *Faker) Pick(options ...string) string {
+	return options[f.Intn(len(options))]
+}
+
+// FirstName returns a first name
+func (f *Faker) FirstName() string {
+	return f.Pick(firstNames...)
+}
+
+// LastName returns a last name
+func (f *Faker) LastName() string {
+	return f.Pick(lastNames...)
+}
+
+// Name returns a first and last name
+func (f *Faker) Name() string {
+	return f.FirstName() + " " + f.LastName()
+}
+
+// Email returns an address at example.com for the name. Every address a
+// faker returns is different, and so are those of other fakers, so they can
+// go in a unique column.
+func (f *Faker) Email(firstName, lastName string) string {
+	f.mu.Lock()
+	f.emails++
+	n := f.emails
+	f.mu.Unlock()
+
+	return fmt.Sprintf("%s.%s%d.%s@example.com", strings.ToLower(firstName), strings.ToLower(lastName), n, f.tag)
+}
+
+// Word returns a word
+func (f *Faker) Word() string {
+	return f.Pick(words...)
+}
+
+// Sentence returns a sentence of n words, or "" when n isn't positive
+func (f *Faker) Sentence(n int) string {
+	if n <= 0 {
+		return ""
+	}
+
+	ws := make([]string, n)
+	for i := range ws {
+		ws[i] = f.Word()
+	}
+	s := strings.Join(ws, " ")
+	return strings.ToUpper(s[:1]) + s[1:] + "."
+}
+
+// TimeBetween returns a time in [from, to), or from when to isn't after it
+func (f *Faker) TimeBetween(from, to time.Time) time.Time {
+	if !to.After(from) {
+		return from
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return from.Add(time.Duration(f.rand.Int63n(int64(to.Sub(from)))))
+}
+
+// PasswordHash returns the bcrypt hash of DefaultPassword. Hashing is slow
+// on purpose, so it is done once per faker and shared by every user.
+func (f *Faker) PasswordHash() (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.hash == "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(DefaultPassword), passwordCost)
+		if err != nil {
+			return "", err
+		}
+		f.hash = string(hash)
+	}
+	return f.hash, nil
+}
@@ -0,0 +1,130 @@
+/*
+Package seed fills a database with development or test data. Seeders live
+in the seeders folder of an app and are made with rkt make seeder: plain
+.sql files, or Go files that register a Func and usually build records with
+a Factory. rkt seed runs them all, in file name order.
+*/
+package seed
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/m-goku/rkt"
+)
+
+// Func is a seeder written in Go
+type Func func(ctx context.Context, app *rkt.RKT) error
+
+var (
+	registryMu sync.Mutex
+	registry   = make(map[string]Func)
+)
+
+// Register adds a Go seeder. Generated seeders call it from init with their
+// file name, without extension, so that they sort with the sql seeders.
+func Register(name string, fn Func) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, ok := registry[name]; ok {
+		panic(fmt.Sprintf("seed: seeder %s is registered twice", name))
+	}
+	registry[name] = fn
+}
+
+// Run runs the .sql files in dir and every registered Go seeder, ordered by
+// name, and returns the names of the seeders it ran. Each sql seeder runs
+// in its own transaction. It stops at the first seeder that fails.
+func Run(ctx context.Context, app *rkt.RKT, dir string) ([]string, error) {
+	seeders := make(map[string]Func)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		seeders[strings.TrimSuffix(filepath.Base(file), ".sql")] = sqlSeeder(file)
+	}
+
+	registryMu.Lock()
+	for name, fn := range registry {
+		seeders[name] = fn
+	}
+	registryMu.Unlock()
+
+	names := make([]string, 0, len(seeders))
+	for name := range seeders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var ran []string
+	for _, name := range names {
+		if err := seeders[name](ctx, app); err != nil {
+			return ran, fmt.Errorf("seed: %s: %w", name, err)
+		}
+		ran = append(ran, name)
+	}
+
+	return ran, nil
+}
+
+// sqlSeeder returns a seeder that runs the statements in file
+func sqlSeeder(file string) Func {
+	return func(ctx context.Context, app *rkt.RKT) error {
+		if app.DB.Pool == nil {
+			return errors.New("sql seeders need a sql database; write a Go seeder for mongodb")
+		}
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		return app.DB.WithTx(ctx, nil, func(tx *sql.Tx) error {
+			for _, stmt := range statements(app.DB.DataType, string(content)) {
+				if _, err := tx.ExecContext(ctx, stmt); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+}
+
+// statements splits a seed file for drivers that run one statement per
+// call. The mysql driver does unless multiStatements is set on the
+// connection, so for mysql the file is split at every line that ends with a
+// semicolon; the other drivers take the whole file at once.
+func statements(dbType, content string) []string {
+	if dbType != "mysql" && dbType != "mariadb" {
+		return []string{content}
+	}
+
+	var stmts []string
+	var current strings.Builder
+	for _, line := range strings.SplitAfter(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if current.Len() == 0 && (trimmed == "" || strings.HasPrefix(trimmed, "--")) {
+			continue
+		}
+
+		current.WriteString(line)
+		if strings.HasSuffix(trimmed, ";") {
+			stmts = append(stmts, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+	}
+	if stmt := strings.TrimSpace(current.String()); stmt != "" {
+		stmts = append(stmts, stmt)
+	}
+	return stmts
+}
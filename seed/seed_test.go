@@ -0,0 +1,189 @@
+package seed
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/m-goku/rkt"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const usersTable = `create table users (
+	id integer primary key autoincrement,
+	first_name text not null,
+	last_name text not null,
+	email text not null unique,
+	user_active integer not null default 0,
+	password text not null,
+	created_at timestamp not null,
+	updated_at timestamp not null
+)`
+
+func newTestApp(t *testing.T) *rkt.RKT {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "seed.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := db.Exec(usersTable); err != nil {
+		t.Fatal(err)
+	}
+	return &rkt.RKT{DB: rkt.Database{DataType: "sqlite", Pool: db}}
+}
+
+func countUsers(t *testing.T, app *rkt.RKT) int {
+	t.Helper()
+
+	var n int
+	if err := app.DB.Pool.QueryRow("select count(*) from users").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestFactory_Create(t *testing.T) {
+	app := newTestApp(t)
+
+	users, err := Users.Create(context.Background(), app, NewFaker(1), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 5 {
+		t.Fatalf("got %d users, want 5", len(users))
+	}
+	if n := countUsers(t, app); n != 5 {
+		t.Fatalf("got %d rows, want 5", n)
+	}
+
+	var hash string
+	if err := app.DB.Pool.QueryRow("select password from users where email = ?", users[0].Email).Scan(&hash); err != nil {
+		t.Fatal(err)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(DefaultPassword)); err != nil {
+		t.Errorf("stored password doesn't match DefaultPassword: %v", err)
+	}
+}
+
+func TestFaker_Deterministic(t *testing.T) {
+	a, b := NewFaker(42), NewFaker(42)
+	for i := 0; i < 10; i++ {
+		if x, y := a.Name(), b.Name(); x != y {
+			t.Fatalf("same seed gave %q and %q", x, y)
+		}
+	}
+
+	first, last := a.FirstName(), a.LastName()
+	if a.Email(first, last) == a.Email(first, last) {
+		t.Error("emails for the same name should be unique")
+	}
+	// a second seeding run must not collide with the first
+	if x, y := a.Email("Ada", "Lopez"), b.Email("Ada", "Lopez"); x == y {
+		t.Errorf("fakers with the same seed both gave %q", x)
+	}
+}
+
+func TestFaker_Empty(t *testing.T) {
+	f := NewFaker(1)
+
+	if s := f.Sentence(0); s != "" {
+		t.Errorf("expected an empty sentence, got %q", s)
+	}
+	if s := f.Sentence(3); len(strings.Fields(s)) != 3 || !strings.HasSuffix(s, ".") {
+		t.Errorf("expected a sentence of 3 words, got %q", s)
+	}
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	if got := f.TimeBetween(from, from); !got.Equal(from) {
+		t.Errorf("expected %v for an empty range, got %v", from, got)
+	}
+	if got := f.TimeBetween(from, from.Add(-time.Hour)); !got.Equal(from) {
+		t.Errorf("expected %v for a reversed range, got %v", from, got)
+	}
+}
+
+func TestInsertQuery(t *testing.T) {
+	user := User{FirstName: "Ada", LastName: "Lovelace"}
+
+	query, args := insertQuery("postgres", "users", user)
+	want := "INSERT INTO users (first_name, last_name, email, user_active, password, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7)"
+	if query != want {
+		t.Errorf("got %q, want %q", query, want)
+	}
+	if len(args) != 7 || args[0] != "Ada" {
+		t.Errorf("unexpected args %v", args)
+	}
+
+	query, _ = insertQuery("mysql", "users", user)
+	if want := "INSERT INTO users (first_name, last_name, email, user_active, password, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)"; query != want {
+		t.Errorf("got %q, want %q", query, want)
+	}
+}
+
+func TestRun(t *testing.T) {
+	app := newTestApp(t)
+	dir := t.TempDir()
+
+	seeders := map[string]string{
+		"1_first.sql": "insert into users (first_name, last_name, email, password, created_at, updated_at) values ('a', 'a', 'a@example.com', 'x', current_timestamp, current_timestamp);",
+		"3_third.sql": "insert into users (first_name, last_name, email, password, created_at, updated_at) values ('c', 'c', 'c@example.com', 'x', current_timestamp, current_timestamp);",
+	}
+	for name, content := range seeders {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	Register("2_second", func(ctx context.Context, app *rkt.RKT) error {
+		_, err := Users.Create(ctx, app, NewFaker(1), 3)
+		return err
+	})
+	t.Cleanup(func() { delete(registry, "2_second") })
+
+	ran, err := Run(context.Background(), app, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"1_first", "2_second", "3_third"}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("ran %v, want %v", ran, want)
+	}
+	if n := countUsers(t, app); n != 5 {
+		t.Errorf("got %d rows, want 5", n)
+	}
+
+	// a failing seeder rolls back its own transaction and stops the run
+	broken := t.TempDir()
+	content := "insert into users (first_name, last_name, email, password, created_at, updated_at) values ('d', 'd', 'd@example.com', 'x', current_timestamp, current_timestamp);\ninsert into nope values (1);"
+	if err := os.WriteFile(filepath.Join(broken, "5_broken.sql"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	delete(registry, "2_second")
+
+	if _, err := Run(context.Background(), app, broken); err == nil {
+		t.Error("expected an error from the broken seeder")
+	}
+	if n := countUsers(t, app); n != 5 {
+		t.Errorf("got %d rows after the failed seeder, want 5", n)
+	}
+}
+
+func TestStatements(t *testing.T) {
+	content := "-- users\ninsert into a values (1);\n\ninsert into b\nvalues (2);\n"
+
+	if got := statements("postgres", content); len(got) != 1 {
+		t.Errorf("postgres: got %d statements, want 1", len(got))
+	}
+
+	want := []string{"insert into a values (1);", "insert into b\nvalues (2);"}
+	if got := statements("mysql", content); !reflect.DeepEqual(got, want) {
+		t.Errorf("mysql: got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,229 @@
+package rkt
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// ListenAndServe starts the web server and blocks until it fails or the
+// process receives SIGINT/SIGTERM. On a signal the server stops accepting
+// connections, drains active requests and releases every backend through
+// Shutdown. The error is returned to the caller instead of exiting.
+//
+// When SECURE is true and TLS_CERT_FILE/TLS_KEY_FILE are set the server
+// speaks HTTPS, and HTTP_REDIRECT_PORT optionally starts a second, plain
+// HTTP listener that redirects every request to the HTTPS port.
+func (r *RKT) ListenAndServe() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := r.Start(ctx)
+	if err != nil {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), r.config.Server.ShutdownTimeout)
+		defer cancel()
+		return errors.Join(err, r.Shutdown(shutdownCtx))
+	}
+
+	r.server = &http.Server{
+		Addr:         ":" + r.config.Server.Port,
+		ErrorLog:     r.ErrorLog,
+		Handler:      r.handler,
+		IdleTimeout:  30 * time.Second,
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 600 * time.Second,
+	}
+
+	serverErr := make(chan error, 2)
+	go func() {
+		var err error
+		if r.tlsEnabled() {
+			r.server.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+			r.InfoLog.Println("Listening for HTTPS on port " + r.config.Server.Port)
+			err = r.server.ListenAndServeTLS(r.config.Server.TLSCertFile, r.config.Server.TLSKeyFile)
+		} else {
+			r.InfoLog.Println("Listening on port " + r.config.Server.Port)
+			err = r.server.ListenAndServe()
+		}
+		if errors.Is(err, http.ErrServerClosed) {
+			err = nil
+		}
+		serverErr <- err
+	}()
+
+	if r.tlsEnabled() && r.config.Server.RedirectPort != "" {
+		r.redirectSrv = &http.Server{
+			Addr:              ":" + r.config.Server.RedirectPort,
+			ErrorLog:          r.ErrorLog,
+			Handler:           http.HandlerFunc(r.redirectToHTTPS),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() {
+			r.InfoLog.Println("Redirecting HTTP on port " + r.config.Server.RedirectPort + " to HTTPS")
+			err := r.redirectSrv.ListenAndServe()
+			if errors.Is(err, http.ErrServerClosed) {
+				err = nil
+			}
+			serverErr <- err
+		}()
+	}
+
+	select {
+	case err = <-serverErr:
+	case <-ctx.Done():
+		r.InfoLog.Println("Shutdown signal received, draining connections")
+	}
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), r.config.Server.ShutdownTimeout)
+	defer cancel()
+
+	return errors.Join(err, r.Shutdown(shutdownCtx))
+}
+
+// tlsEnabled reports whether the server should terminate TLS itself
+func (r *RKT) tlsEnabled() bool {
+	return r.Server.Secure && r.config.Server.TLSCertFile != "" && r.config.Server.TLSKeyFile != ""
+}
+
+// redirectToHTTPS sends a permanent redirect to the same path on the HTTPS port
+func (r *RKT) redirectToHTTPS(w http.ResponseWriter, req *http.Request) {
+	host, _, err := net.SplitHostPort(req.Host)
+	if err != nil {
+		host = req.Host
+	}
+	if r.config.Server.Port != "" && r.config.Server.Port != "443" {
+		host = net.JoinHostPort(host, r.config.Server.Port)
+	}
+
+	target := url.URL{
+		Scheme:   "https",
+		Host:     host,
+		Path:     req.URL.Path,
+		RawQuery: req.URL.RawQuery,
+	}
+	http.Redirect(w, req, target.String(), http.StatusMovedPermanently)
+}
+
+// Shutdown stops the http server, the scheduler, the registered services and
+// the shutdown hooks, then flushes and closes the cache and database
+// connections in that order. Every step runs even if an earlier one fails;
+// all errors are joined.
+func (r *RKT) Shutdown(ctx context.Context) error {
+	var errs []error
+
+	// fail readiness first so load balancers stop routing here
+	r.shuttingDown.Store(true)
+
+	if r.redirectSrv != nil {
+		if err := r.redirectSrv.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if r.server != nil {
+		if err := r.server.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if r.Scheduler != nil {
+		select {
+		case <-r.Scheduler.Stop().Done():
+		case <-ctx.Done():
+			errs = append(errs, errors.New("scheduler: running jobs did not finish before shutdown timeout"))
+		}
+	}
+
+	if err := stopServices(ctx, r.started); err != nil {
+		errs = append(errs, err)
+	}
+	r.started = nil
+
+	for i := len(r.shutdownHooks) - 1; i >= 0; i-- {
+		if err := r.shutdownHooks[i](ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	errs = append(errs, r.closeBackends(ctx)...)
+
+	// the log file goes last so every step above can still be logged
+	if r.logFile != nil {
+		if err := r.logFile.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Close shuts the instance down within the configured shutdown timeout.
+// It is meant for apps and tests that never call ListenAndServe.
+func (r *RKT) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), r.config.Server.ShutdownTimeout)
+	defer cancel()
+
+	return r.Shutdown(ctx)
+}
+
+// closeBackends flushes badger, stops the session cleanup and closes the
+// cache and database connections. It only does the work once, so Shutdown
+// can safely be called again.
+func (r *RKT) closeBackends(ctx context.Context) []error {
+	r.closeOnce.Do(func() {
+		var errs []error
+
+		if r.badgerConn != nil {
+			if err := r.badgerConn.Sync(); err != nil {
+				errs = append(errs, err)
+			}
+			if err := r.badgerConn.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		if r.redisPool != nil {
+			if err := r.redisPool.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		// the sql session stores clean up expired sessions from a goroutine
+		// that uses the pool
+		if r.Session != nil {
+			if store, ok := r.Session.Store.(interface{ StopCleanup() }); ok {
+				store.StopCleanup()
+			}
+		}
+
+		if r.DB.replicas != nil {
+			if err := r.DB.replicas.close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		if r.DB.Pool != nil {
+			if err := r.DB.Pool.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		if r.DB.Conn != nil {
+			if err := r.DB.Conn.Disconnect(ctx); err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		r.closeErrs = errs
+	})
+
+	return r.closeErrs
+}
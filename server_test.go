@@ -0,0 +1,86 @@
+package rkt
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRKT_RedirectToHTTPS(t *testing.T) {
+	tests := []struct {
+		port, target, want string
+	}{
+		{"4000", "http://example.com:8080/users/1?tab=posts&page=2", "https://example.com:4000/users/1?tab=posts&page=2"},
+		{"443", "http://example.com:8080/login", "https://example.com/login"},
+		{"4000", "http://[::1]:8080/", "https://[::1]:4000/"},
+	}
+
+	for _, tt := range tests {
+		app := &RKT{}
+		app.config.Server.Port = tt.port
+
+		rec := httptest.NewRecorder()
+		app.redirectToHTTPS(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+		if rec.Code != http.StatusMovedPermanently {
+			t.Errorf("%s: expected 301, got %d", tt.target, rec.Code)
+		}
+		if got := rec.Header().Get("Location"); got != tt.want {
+			t.Errorf("%s: expected a redirect to %s, got %s", tt.target, tt.want, got)
+		}
+	}
+}
+
+func TestRKT_HSTS(t *testing.T) {
+	app := &RKT{}
+	app.config.Server.HSTSMaxAge = 600
+	handler := app.HSTS(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "https://example.com/", nil)
+	req.TLS = &tls.ConnectionState{}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if got := rec.Header().Get("Strict-Transport-Security"); got != "max-age=600; includeSubDomains" {
+		t.Errorf("expected the configured max-age over tls, got %q", got)
+	}
+
+	// browsers ignore the header over plain http, and it must not leak there
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://example.com/", nil))
+	if got := rec.Header().Get("Strict-Transport-Security"); got != "" {
+		t.Errorf("expected no header over plain http, got %q", got)
+	}
+
+	app.config.Server.HSTSMaxAge = 0
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if got := rec.Header().Get("Strict-Transport-Security"); got != "" {
+		t.Errorf("expected a zero max-age to turn the header off, got %q", got)
+	}
+}
+
+func TestRKT_TLSEnabled(t *testing.T) {
+	tests := []struct {
+		name      string
+		secure    bool
+		cert, key string
+		want      bool
+	}{
+		{"secure with both files", true, "tls/cert.pem", "tls/key.pem", true},
+		{"no key file", true, "tls/cert.pem", "", false},
+		{"no cert file", true, "", "tls/key.pem", false},
+		{"no files", true, "", "", false},
+		{"not secure", false, "tls/cert.pem", "tls/key.pem", false},
+	}
+
+	for _, tt := range tests {
+		app := &RKT{Server: Server{Secure: tt.secure}}
+		app.config.Server.TLSCertFile = tt.cert
+		app.config.Server.TLSKeyFile = tt.key
+
+		if got := app.tlsEnabled(); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
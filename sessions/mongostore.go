@@ -0,0 +1,115 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+/*
+MongoStore is an scs session store backed by a mongodb collection named
+sessions. Each session is a document with the token as _id, the encoded
+data, and an expiry date. A TTL index on expiry lets mongodb delete expired
+sessions; since its TTL monitor only runs once a minute, Find also skips
+sessions that have expired but are not deleted yet.
+*/
+type MongoStore struct {
+	collection *mongo.Collection
+}
+
+type mongoSession struct {
+	Token  string    `bson:"_id"`
+	Data   []byte    `bson:"data"`
+	Expiry time.Time `bson:"expiry"`
+}
+
+// NewMongoStore returns a store using the sessions collection of db
+func NewMongoStore(db *mongo.Database) *MongoStore {
+	return &MongoStore{collection: db.Collection("sessions")}
+}
+
+// EnsureIndexes creates the TTL index that expires sessions. rkt make
+// session creates it through a migration; this is for apps that don't
+// use migrations.
+func (m *MongoStore) EnsureIndexes(ctx context.Context) error {
+	_, err := m.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiry", Value: 1}},
+		Options: options.Index().SetName("sessions_expiry_ttl").SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+// Find returns the data for a session token that exists and has not expired
+func (m *MongoStore) Find(token string) ([]byte, bool, error) {
+	return m.FindCtx(context.Background(), token)
+}
+
+// FindCtx is Find with a context
+func (m *MongoStore) FindCtx(ctx context.Context, token string) ([]byte, bool, error) {
+	var s mongoSession
+	err := m.collection.FindOne(ctx, bson.M{
+		"_id":    token,
+		"expiry": bson.M{"$gt": time.Now()},
+	}).Decode(&s)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	return s.Data, true, nil
+}
+
+// Commit adds or replaces a session token and its data
+func (m *MongoStore) Commit(token string, b []byte, expiry time.Time) error {
+	return m.CommitCtx(context.Background(), token, b, expiry)
+}
+
+// CommitCtx is Commit with a context
+func (m *MongoStore) CommitCtx(ctx context.Context, token string, b []byte, expiry time.Time) error {
+	_, err := m.collection.ReplaceOne(ctx,
+		bson.M{"_id": token},
+		mongoSession{Token: token, Data: b, Expiry: expiry},
+		options.Replace().SetUpsert(true),
+	)
+	return err
+}
+
+// Delete removes a session token and its data
+func (m *MongoStore) Delete(token string) error {
+	return m.DeleteCtx(context.Background(), token)
+}
+
+// DeleteCtx is Delete with a context
+func (m *MongoStore) DeleteCtx(ctx context.Context, token string) error {
+	_, err := m.collection.DeleteOne(ctx, bson.M{"_id": token})
+	return err
+}
+
+// All returns every session that has not expired, keyed by token
+func (m *MongoStore) All() (map[string][]byte, error) {
+	return m.AllCtx(context.Background())
+}
+
+// AllCtx is All with a context
+func (m *MongoStore) AllCtx(ctx context.Context) (map[string][]byte, error) {
+	cursor, err := m.collection.Find(ctx, bson.M{"expiry": bson.M{"$gt": time.Now()}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	all := make(map[string][]byte)
+	for cursor.Next(ctx) {
+		var s mongoSession
+		if err := cursor.Decode(&s); err != nil {
+			return nil, err
+		}
+		all[s.Token] = s.Data
+	}
+
+	return all, cursor.Err()
+}
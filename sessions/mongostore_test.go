@@ -0,0 +1,110 @@
+package sessions
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TestMongoStore runs against a real server. It needs RKT_TEST_MONGODB_DSN
+// to point at one; the test works in a database of its own and drops it.
+func TestMongoStore(t *testing.T) {
+	dsn := os.Getenv("RKT_TEST_MONGODB_DSN")
+	if dsn == "" {
+		t.Skip("RKT_TEST_MONGODB_DSN is not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(dsn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Disconnect(context.Background())
+
+	db := client.Database(fmt.Sprintf("rkt_session_test_%d", time.Now().UnixNano()))
+	defer db.Drop(context.Background())
+
+	store := NewMongoStore(db)
+
+	if err := store.EnsureIndexes(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// creating it again is a no-op
+	if err := store.EnsureIndexes(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	cursor, err := db.Collection("sessions").Indexes().List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cursor.Close(ctx)
+
+	found := false
+	for cursor.Next(ctx) {
+		var index bson.M
+		if err := cursor.Decode(&index); err != nil {
+			t.Fatal(err)
+		}
+		if index["name"] == "sessions_expiry_ttl" {
+			found = true
+			// the server may hand the number back as any numeric type
+			if ttl := fmt.Sprint(index["expireAfterSeconds"]); ttl != "0" {
+				t.Errorf("expected the index to expire sessions at their expiry, got %v", index)
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !found {
+		t.Error("expected the sessions_expiry_ttl index")
+	}
+
+	if err := store.CommitCtx(ctx, "live", []byte("first"), time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	// a second commit replaces the data
+	if err := store.CommitCtx(ctx, "live", []byte("second"), time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CommitCtx(ctx, "expired", []byte("old"), time.Now().Add(-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	data, ok, err := store.FindCtx(ctx, "live")
+	if err != nil || !ok || string(data) != "second" {
+		t.Errorf("expected the latest data of a live session, got %q %v %v", data, ok, err)
+	}
+
+	// the TTL monitor only runs once a minute, so Find has to skip it
+	if _, ok, err := store.FindCtx(ctx, "expired"); err != nil || ok {
+		t.Errorf("expected an expired session not to be found, got %v %v", ok, err)
+	}
+	if _, ok, err := store.FindCtx(ctx, "missing"); err != nil || ok {
+		t.Errorf("expected an unknown token not to be found, got %v %v", ok, err)
+	}
+
+	all, err := store.AllCtx(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 1 || string(all["live"]) != "second" {
+		t.Errorf("expected only the live session, got %v", all)
+	}
+
+	if err := store.DeleteCtx(ctx, "live"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := store.FindCtx(ctx, "live"); err != nil || ok {
+		t.Errorf("expected a deleted session not to be found, got %v %v", ok, err)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/alexedwards/scs/redisstore"
 	"github.com/alexedwards/scs/v2"
 	"github.com/gomodule/redigo/redis"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type Session struct {
@@ -23,6 +24,7 @@ type Session struct {
 	CookieSecure   string
 	DBPool         *sql.DB
 	RedisPool      *redis.Pool
+	MongoDB        *mongo.Database
 }
 
 func (s *Session) InitSession() *scs.SessionManager {
@@ -50,7 +52,9 @@ func (s *Session) InitSession() *scs.SessionManager {
 	session.Lifetime = time.Duration(minutes) * time.Minute
 	session.Cookie.Persist = persist
 	session.Cookie.Secure = secure
-	session.Cookie.Name = s.CookieName
+	if s.CookieName != "" {
+		session.Cookie.Name = s.CookieName
+	}
 	session.Cookie.Domain = s.CookieDomain
 	session.Cookie.SameSite = http.SameSiteLaxMode
 
@@ -62,6 +66,10 @@ func (s *Session) InitSession() *scs.SessionManager {
 		session.Store = mysqlstore.New(s.DBPool)
 	case "postgres", "postgresql":
 		session.Store = postgresstore.New(s.DBPool)
+	case "sqlite":
+		session.Store = NewSQLiteStore(s.DBPool)
+	case "mongodb":
+		session.Store = NewMongoStore(s.MongoDB)
 	default:
 		//cookies
 	}
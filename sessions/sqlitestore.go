@@ -0,0 +1,116 @@
+package sessions
+
+import (
+	"database/sql"
+	"log"
+	"sync"
+	"time"
+)
+
+/*
+SQLiteStore is an scs session store backed by a sqlite database. It expects
+the sessions table created by rkt make session:
+
+	CREATE TABLE sessions (
+		token TEXT PRIMARY KEY,
+		data BLOB NOT NULL,
+		expiry INTEGER NOT NULL
+	);
+
+expiry holds unix milliseconds. Expired sessions are deleted every five
+minutes until StopCleanup is called.
+*/
+type SQLiteStore struct {
+	db          *sql.DB
+	stopCleanup chan struct{}
+	stopOnce    sync.Once
+}
+
+// NewSQLiteStore returns a store using db, with a cleanup every five minutes
+func NewSQLiteStore(db *sql.DB) *SQLiteStore {
+	return NewSQLiteStoreWithCleanupInterval(db, 5*time.Minute)
+}
+
+// NewSQLiteStoreWithCleanupInterval returns a store using db. A zero interval
+// turns the cleanup of expired sessions off.
+func NewSQLiteStoreWithCleanupInterval(db *sql.DB, interval time.Duration) *SQLiteStore {
+	s := &SQLiteStore{db: db}
+	if interval > 0 {
+		s.stopCleanup = make(chan struct{})
+		go s.startCleanup(interval)
+	}
+	return s
+}
+
+// Find returns the data for a session token that exists and has not expired
+func (s *SQLiteStore) Find(token string) ([]byte, bool, error) {
+	var b []byte
+	row := s.db.QueryRow("SELECT data FROM sessions WHERE token = ? AND expiry > ?", token, time.Now().UnixMilli())
+	err := row.Scan(&b)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+	return b, true, nil
+}
+
+// Commit adds or replaces a session token and its data
+func (s *SQLiteStore) Commit(token string, b []byte, expiry time.Time) error {
+	_, err := s.db.Exec(`INSERT INTO sessions (token, data, expiry) VALUES (?, ?, ?)
+		ON CONFLICT (token) DO UPDATE SET data = excluded.data, expiry = excluded.expiry`,
+		token, b, expiry.UnixMilli())
+	return err
+}
+
+// Delete removes a session token and its data
+func (s *SQLiteStore) Delete(token string) error {
+	_, err := s.db.Exec("DELETE FROM sessions WHERE token = ?", token)
+	return err
+}
+
+// All returns every session that has not expired, keyed by token
+func (s *SQLiteStore) All() (map[string][]byte, error) {
+	rows, err := s.db.Query("SELECT token, data FROM sessions WHERE expiry > ?", time.Now().UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	all := make(map[string][]byte)
+	for rows.Next() {
+		var token string
+		var data []byte
+		if err := rows.Scan(&token, &data); err != nil {
+			return nil, err
+		}
+		all[token] = data
+	}
+
+	return all, rows.Err()
+}
+
+// StopCleanup ends the background cleanup of expired sessions. It can be
+// called more than once.
+func (s *SQLiteStore) StopCleanup() {
+	if s.stopCleanup != nil {
+		s.stopOnce.Do(func() { close(s.stopCleanup) })
+	}
+}
+
+func (s *SQLiteStore) startCleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			_, err := s.db.Exec("DELETE FROM sessions WHERE expiry < ?", time.Now().UnixMilli())
+			if err != nil {
+				log.Println(err)
+			}
+		case <-s.stopCleanup:
+			return
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package sessions
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestSQLiteStore_StopCleanup(t *testing.T) {
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "sessions.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	// the cleanup goroutine and the test share the file
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec("CREATE TABLE sessions (token TEXT PRIMARY KEY, data BLOB NOT NULL, expiry INTEGER NOT NULL)"); err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewSQLiteStoreWithCleanupInterval(db, 10*time.Millisecond)
+	if err := store.Commit("expired", []byte("old"), time.Now().Add(-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	// wait for the cleanup to delete the expired session
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var n int
+		if err := db.QueryRow("SELECT count(*) FROM sessions").Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the cleanup to delete the expired session")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// the app stops the cleanup on close, which may happen more than once
+	done := make(chan struct{})
+	go func() {
+		store.StopCleanup()
+		store.StopCleanup()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected StopCleanup not to block")
+	}
+
+	// a store without a cleanup has nothing to stop
+	NewSQLiteStoreWithCleanupInterval(db, 0).StopCleanup()
+}
@@ -0,0 +1,146 @@
+package rkt
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgconn"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// txAttempts is how many times WithTx runs a transaction that keeps failing
+// on serialization conflicts or deadlocks
+const txAttempts = 3
+
+// ErrNoDatabase is returned by the transaction helpers when no database of
+// the right kind is connected
+var ErrNoDatabase = errors.New("rkt: no database connected")
+
+var savepointID atomic.Uint64
+
+/*
+WithTx runs fn in a transaction on the primary. The transaction is committed
+when fn returns nil and rolled back when it returns an error or panics; a
+panic is turned into an error. When postgres reports a serialization failure
+(40001) or deadlock (40P01), or mysql a deadlock (1213), the whole
+transaction is run again, up to three times, so fn must not have side
+effects outside the transaction. Use Savepoint for nested transactions.
+*/
+func (d Database) WithTx(ctx context.Context, opts *sql.TxOptions, fn func(tx *sql.Tx) error) error {
+	if d.Pool == nil {
+		return ErrNoDatabase
+	}
+
+	var err error
+	for attempt := 1; attempt <= txAttempts; attempt++ {
+		err = runTx(ctx, d.Pool, opts, fn)
+		if err == nil || !isRetryable(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(time.Duration(attempt) * 10 * time.Millisecond):
+		}
+	}
+
+	return err
+}
+
+func runTx(ctx context.Context, db *sql.DB, opts *sql.TxOptions, fn func(tx *sql.Tx) error) (err error) {
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("rkt: panic in transaction: %v", p)
+		}
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+				err = errors.Join(err, rbErr)
+			}
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	return fn(tx)
+}
+
+// Savepoint runs fn inside a savepoint of tx. When fn returns an error or
+// panics, only the work done since the savepoint is rolled back and the
+// error is returned; tx itself stays usable.
+func Savepoint(ctx context.Context, tx *sql.Tx, fn func(tx *sql.Tx) error) (err error) {
+	name := fmt.Sprintf("rkt_sp_%d", savepointID.Add(1))
+
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("rkt: panic in savepoint: %v", p)
+		}
+		if err != nil {
+			if _, rbErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); rbErr != nil {
+				err = errors.Join(err, rbErr)
+			}
+			return
+		}
+		_, err = tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+	}()
+
+	return fn(tx)
+}
+
+// isRetryable reports whether err means the transaction lost a race with
+// another one and can simply be run again
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "40001" || pgErr.Code == "40P01"
+	}
+
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) {
+		return myErr.Number == 1213
+	}
+
+	return false
+}
+
+// WithMongoTx runs fn in a transaction on a new mongo session. The driver
+// commits when fn returns nil, aborts otherwise, and retries the transaction
+// and its commit on transient errors. Transactions need a replica set or a
+// sharded cluster.
+func (d Database) WithMongoTx(ctx context.Context, fn func(sc mongo.SessionContext) error, opts ...*options.TransactionOptions) error {
+	if d.Conn == nil {
+		return ErrNoDatabase
+	}
+
+	session, err := d.Conn.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (result interface{}, err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = fmt.Errorf("rkt: panic in transaction: %v", p)
+			}
+		}()
+		return nil, fn(sc)
+	}, opts...)
+
+	return err
+}
@@ -0,0 +1,139 @@
+package rkt
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgconn"
+)
+
+// newItemsDB boots a sqlite app and gives its database an items table
+func newItemsDB(t *testing.T) Database {
+	t.Helper()
+
+	d := newTestApp(t, withSQLite).DB
+	if _, err := d.Pool.Exec("create table items (name text not null)"); err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func countItems(t *testing.T, d Database) int {
+	t.Helper()
+
+	var n int
+	if err := d.Pool.QueryRow("select count(*) from items").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestDatabase_WithTx(t *testing.T) {
+	ctx := context.Background()
+	d := newItemsDB(t)
+
+	insert := func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, "insert into items (name) values ('a')")
+		return err
+	}
+
+	if err := d.WithTx(ctx, nil, insert); err != nil {
+		t.Fatal(err)
+	}
+	if n := countItems(t, d); n != 1 {
+		t.Fatalf("expected the insert to be committed, got %d rows", n)
+	}
+
+	boom := errors.New("boom")
+	err := d.WithTx(ctx, nil, func(tx *sql.Tx) error {
+		if err := insert(tx); err != nil {
+			return err
+		}
+		return boom
+	})
+	if !errors.Is(err, boom) {
+		t.Errorf("expected the error from fn, got %v", err)
+	}
+
+	err = d.WithTx(ctx, nil, func(tx *sql.Tx) error {
+		_ = insert(tx)
+		panic("oops")
+	})
+	if err == nil {
+		t.Error("expected the panic to be returned as an error")
+	}
+
+	if n := countItems(t, d); n != 1 {
+		t.Errorf("expected failed transactions to be rolled back, got %d rows", n)
+	}
+}
+
+func TestDatabase_WithTxRetry(t *testing.T) {
+	ctx := context.Background()
+	d := newItemsDB(t)
+
+	attempts := 0
+	err := d.WithTx(ctx, nil, func(tx *sql.Tx) error {
+		attempts++
+		if _, err := tx.ExecContext(ctx, "insert into items (name) values ('a')"); err != nil {
+			return err
+		}
+		if attempts < 3 {
+			return &pgconn.PgError{Code: "40001"}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+	if n := countItems(t, d); n != 1 {
+		t.Errorf("expected only the last attempt to be committed, got %d rows", n)
+	}
+
+	attempts = 0
+	_ = d.WithTx(ctx, nil, func(tx *sql.Tx) error {
+		attempts++
+		return errors.New("not retryable")
+	})
+	if attempts != 1 {
+		t.Errorf("expected other errors not to be retried, got %d attempts", attempts)
+	}
+}
+
+func TestSavepoint(t *testing.T) {
+	ctx := context.Background()
+	d := newItemsDB(t)
+
+	err := d.WithTx(ctx, nil, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "insert into items (name) values ('outer')"); err != nil {
+			return err
+		}
+
+		err := Savepoint(ctx, tx, func(tx *sql.Tx) error {
+			if _, err := tx.ExecContext(ctx, "insert into items (name) values ('inner')"); err != nil {
+				return err
+			}
+			return errors.New("undo the inner insert")
+		})
+		if err == nil {
+			t.Error("expected the savepoint error to be returned")
+		}
+
+		return Savepoint(ctx, tx, func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, "insert into items (name) values ('kept')")
+			return err
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := countItems(t, d); n != 2 {
+		t.Errorf("expected the outer and kept rows only, got %d rows", n)
+	}
+}
@@ -11,27 +11,11 @@ type initPaths struct {
 	folderNames []string
 }
 
-type cookieConfig struct {
-	name     string
-	lifetime string
-	persists string
-	secure   string
-	domain   string
-}
-
-type databaseConfig struct {
-	dsn      string
-	database string
-}
-
+// Database holds the connection opened by New. Pool is the primary of a
+// sql database; reads can be sent to replicas through Reader.
 type Database struct {
 	DataType string
 	Pool     *sql.DB
 	Conn     *mongo.Client
-}
-
-type redisConfig struct {
-	host     string
-	password string
-	prefix   string
+	replicas *replicaSet
 }